@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var promoteAdminCmd = &cobra.Command{
+	Use:   "promote-admin <user id>",
+	Short: "give an existing user the admin role",
+	Long: `promote-admin sets the role of an existing user to admin directly in
+the database. PUT /admin/users/{id}/role already requires an admin, so
+this is how the first admin is created.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		PromoteAdmin(args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteAdminCmd)
+}
+
+func PromoteAdmin(id string) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.Level(Verbose))
+
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		logger.WithField("method", "PromoteAdmin").Fatal("Invalid user id")
+	}
+
+	db := initPg(logger)
+	defer db.Close()
+
+	err = dal.NewUserDAL(db).UpdateUserRole(userID, models.RoleAdmin)
+	if err == pg.ErrNoRows {
+		logger.WithField("method", "PromoteAdmin").Fatal("User not found")
+	}
+	if err != nil {
+		logger.WithField("method", "PromoteAdmin").Fatal(err)
+	}
+	logger.WithFields(logrus.Fields{"method": "PromoteAdmin", "user_id": userID}).Info("User promoted to admin")
+}
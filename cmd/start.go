@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/go-pg/pg"
 	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
 	"github.com/guilhermeCoutinho/worlds-api/dal"
 	"github.com/guilhermeCoutinho/worlds-api/handler"
+	"github.com/guilhermeCoutinho/worlds-api/models"
 	"github.com/guilhermeCoutinho/worlds-api/services"
 	"github.com/guilhermeCoutinho/worlds-api/utils"
 	"github.com/sirupsen/logrus"
@@ -19,11 +21,18 @@ import (
 )
 
 type App struct {
-	Router     *mux.Router
-	AuthRouter *mux.Router
-	DAL        *dal.DAL
-	Services   *services.Services
-	logger     *logrus.Logger
+	Router *mux.Router
+	// PublicRouter is matched before AuthRouter so public routes such as
+	// /worlds/search are not shadowed by authenticated ones like /worlds/{id}
+	PublicRouter *mux.Router
+	AuthRouter   *mux.Router
+	// AdminRouter is mounted under /admin on AuthRouter and only lets admins through
+	AdminRouter *mux.Router
+	// InternalRouter serves /internal to other deployments holding a service token
+	InternalRouter *mux.Router
+	DAL            *dal.DAL
+	Services       *services.Services
+	logger         *logrus.Logger
 }
 
 var startCmd = &cobra.Command{
@@ -46,7 +55,7 @@ func init() {
 }
 
 func NewApp() *App {
-	config := viper.New()
+	config := initConfig()
 	logger := logrus.New()
 	logger.SetLevel(logrus.Level(Verbose))
 	//	logger.SetFormatter(&logrus.JSONFormatter{})
@@ -59,14 +68,20 @@ func NewApp() *App {
 	services := services.NewServices(config, dal, logger, eventPublisher)
 
 	router := mux.NewRouter()
+	internalRouter := router.PathPrefix("/internal").Subrouter()
+	publicRouter := router.PathPrefix("/").Subrouter()
 	authRouter := router.PathPrefix("/").Subrouter()
+	adminRouter := authRouter.PathPrefix("/admin").Subrouter()
 
 	app := &App{
-		Router:     router,
-		AuthRouter: authRouter,
-		DAL:        dal,
-		Services:   services,
-		logger:     logger,
+		Router:         router,
+		PublicRouter:   publicRouter,
+		AuthRouter:     authRouter,
+		AdminRouter:    adminRouter,
+		InternalRouter: internalRouter,
+		DAL:            dal,
+		Services:       services,
+		logger:         logger,
 	}
 
 	app.SetupRoutes()
@@ -74,6 +89,14 @@ func NewApp() *App {
 	return app
 }
 
+func initConfig() *viper.Viper {
+	// config keys map to environment variables, e.g. auth.dev_mode => AUTH_DEV_MODE
+	config := viper.New()
+	config.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	config.AutomaticEnv()
+	return config
+}
+
 func initPg(logger *logrus.Logger) *pg.DB {
 	logger.WithField("method", "initPg").Info("Connecting to postgres")
 	pgURL := os.Getenv("PG_URL")
@@ -133,18 +156,25 @@ func initRedis(logger *logrus.Logger) *redis.Client {
 
 func (a *App) SetupRoutes() {
 	handlers := handler.NewHandlers(a.Services, a.logger.WithField("method", "SetupRoutes"))
-	handlers.RegisterRoutes(a.Router)
+	handlers.RegisterRoutes(a.PublicRouter)
+	handlers.RegisterAdminRoutes(a.AdminRouter)
 	handlers.RegisterAuthenticatedRoutes(a.AuthRouter)
+	handlers.RegisterInternalRoutes(a.InternalRouter)
 }
 
 func (a *App) SetupMiddlewares() {
 	a.Router.Use(a.LoggingMiddleware)
 
-	authMiddleware := handler.NewAuthMiddleware(a.logger.WithField("method", "SetupMiddlewares"))
+	authMiddleware := handler.NewAuthMiddleware(a.Services, a.logger.WithField("method", "SetupMiddlewares"))
+	a.PublicRouter.Use(authMiddleware.OptionalAuthenticate)
 	a.AuthRouter.Use(authMiddleware.Authenticate)
+	a.AdminRouter.Use(authMiddleware.RequireRole(models.RoleAdmin))
+	a.InternalRouter.Use(authMiddleware.ServiceAuthenticate)
 
 	a.Router.Use(mux.CORSMethodMiddleware(a.Router))
+	a.PublicRouter.Use(mux.CORSMethodMiddleware(a.PublicRouter))
 	a.AuthRouter.Use(mux.CORSMethodMiddleware(a.AuthRouter))
+	a.InternalRouter.Use(mux.CORSMethodMiddleware(a.InternalRouter))
 }
 
 func (a *App) LoggingMiddleware(next http.Handler) http.Handler {
@@ -169,6 +199,13 @@ func (a *App) LoggingMiddleware(next http.Handler) http.Handler {
 
 func (a *App) Run() {
 	logger := a.logger.WithField("method", "Run")
+	if err := a.Services.WorldsService.StartPresenceSweeper(context.Background()); err != nil {
+		logger.Fatal(err)
+	}
+	if err := a.Services.WorldsService.StartDeletedWorldsPurger(context.Background()); err != nil {
+		logger.Fatal(err)
+	}
+
 	logger.Info("Starting server on port 8080")
 	err := http.ListenAndServe(":8080", a.Router)
 	if err != nil {
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var transferWorkerCmd = &cobra.Command{
+	Use:   "transfer-worker",
+	Short: "ship transfer jobs to their target environment",
+	Long: `transfer-worker consumes the world transfer events published by
+POST /worlds/import and pushes each world to the ingest API of the
+job's target environment.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		StartTransferWorker()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(transferWorkerCmd)
+}
+
+func StartTransferWorker() {
+	fmt.Println("Starting transfer worker...")
+	config := initConfig()
+	logger := logrus.New()
+	logger.SetLevel(logrus.Level(Verbose))
+
+	db := initPg(logger)
+	redisClient := initRedis(logger)
+	dal := dal.NewDAL(db, redisClient)
+
+	worker := services.NewTransferWorker(
+		config,
+		dal,
+		logger,
+		services.NewEnvironmentRegistry(config, logger),
+		services.NewEnvironmentClient(logger),
+		services.NewRedisEventSubscriber(redisClient, logger),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := worker.Run(ctx); err != nil {
+		logger.WithField("method", "StartTransferWorker").Fatal(err)
+	}
+}
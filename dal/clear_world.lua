@@ -0,0 +1,19 @@
+-- ARGV[1] = worldId
+-- removes every user from the world and returns their ids
+
+local worldKey = "world:" .. ARGV[1] .. ":users"
+local users = redis.call("SMEMBERS", worldKey)
+
+for _, userId in ipairs(users) do
+    local userKey = "user:" .. userId .. ":world"
+    if redis.call("GET", userKey) == ARGV[1] then
+        redis.call("DEL", userKey)
+        redis.call("ZREM", "presence:last_seen", userId)
+        redis.call("HDEL", "presence:worlds", userId)
+    end
+end
+
+redis.call("DEL", worldKey)
+redis.call("ZREM", "worlds:user_count", ARGV[1])
+
+return users
@@ -7,10 +7,15 @@ import (
 )
 
 type DAL struct {
-	db                    *pg.DB
-	WorldsDAL             WorldsDAL
-	UserDAL               UserDAL
-	WorldsTransferJobsDAL WorldsTransferJobsDAL
+	db                         *pg.DB
+	WorldsDAL                  WorldsDAL
+	UserDAL                    UserDAL
+	WorldsTransferJobsDAL      WorldsTransferJobsDAL
+	WorldMembersDAL            WorldMembersDAL
+	WorldRevisionsDAL          WorldRevisionsDAL
+	WorldInvitesDAL            WorldInvitesDAL
+	WorldBansDAL               WorldBansDAL
+	WorldOwnershipTransfersDAL WorldOwnershipTransfersDAL
 }
 
 func ConnectDB(config *viper.Viper) *pg.DB {
@@ -24,9 +29,14 @@ func ConnectDB(config *viper.Viper) *pg.DB {
 
 func NewDAL(db *pg.DB, redisClient *redis.Client) *DAL {
 	return &DAL{
-		db:                    db,
-		WorldsDAL:             NewWorldsDAL(db, redisClient),
-		UserDAL:               NewUserDAL(db),
-		WorldsTransferJobsDAL: NewWorldsTransferJobsDAL(db),
+		db:                         db,
+		WorldsDAL:                  NewWorldsDAL(db, redisClient),
+		UserDAL:                    NewUserDAL(db),
+		WorldsTransferJobsDAL:      NewWorldsTransferJobsDAL(db),
+		WorldMembersDAL:            NewWorldMembersDAL(db),
+		WorldRevisionsDAL:          NewWorldRevisionsDAL(db),
+		WorldInvitesDAL:            NewWorldInvitesDAL(db),
+		WorldBansDAL:               NewWorldBansDAL(db),
+		WorldOwnershipTransfersDAL: NewWorldOwnershipTransfersDAL(db),
 	}
 }
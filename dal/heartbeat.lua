@@ -0,0 +1,15 @@
+-- KEYS[1] = userId
+-- ARGV[1] = presence TTL in seconds
+-- returns the user's current world, or "" if their presence already expired
+
+local userKey = "user:" .. KEYS[1] .. ":world"
+local currentWorld = redis.call("GET", userKey)
+
+if not currentWorld or currentWorld == "" then
+    return ""
+end
+
+redis.call("EXPIRE", userKey, ARGV[1])
+redis.call("ZADD", "presence:last_seen", redis.call("TIME")[1], KEYS[1])
+
+return currentWorld
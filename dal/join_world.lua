@@ -1,16 +1,43 @@
 -- KEYS[1] = userId
 -- ARGV[1] = newWorldId
+-- ARGV[2] = presence TTL in seconds
+-- ARGV[3] = max players in the new world, 0 for unlimited
+-- returns 1 when the user is in the new world, 0 when the world is full
 
 local userKey = "user:" .. KEYS[1] .. ":world"
+local userCountKey = "worlds:user_count"
+-- the user key expires with the presence TTL, the hash keeps the world until
+-- the sweeper or a leave removes the user from it
 local oldWorld = redis.call("GET", userKey)
+if not oldWorld or oldWorld == "" then
+    oldWorld = redis.call("HGET", "presence:worlds", KEYS[1])
+end
+local worldKey = "world:" .. ARGV[1] .. ":users"
+
+local maxPlayers = tonumber(ARGV[3])
+if maxPlayers > 0 and redis.call("SISMEMBER", worldKey, KEYS[1]) == 0 then
+    if redis.call("SCARD", worldKey) >= maxPlayers then
+        return 0
+    end
+end
 
 if oldWorld and oldWorld ~= "" then
-    redis.call("SREM", "world:" .. oldWorld .. ":users", KEYS[1])
+    if redis.call("SREM", "world:" .. oldWorld .. ":users", KEYS[1]) == 1 then
+        if tonumber(redis.call("ZINCRBY", userCountKey, -1, oldWorld)) <= 0 then
+            redis.call("ZREM", userCountKey, oldWorld)
+        end
+    end
 end
 
-redis.call("SET", userKey, ARGV[1])
+redis.call("SET", userKey, ARGV[1], "EX", ARGV[2])
 
-local worldKey = "world:" .. ARGV[1] .. ":users"
-redis.call("SADD", worldKey, KEYS[1])
+-- set members can't expire, so the sweeper uses these to find users that stopped sending heartbeats
+local now = redis.call("TIME")[1]
+redis.call("ZADD", "presence:last_seen", now, KEYS[1])
+redis.call("HSET", "presence:worlds", KEYS[1], ARGV[1])
+
+if redis.call("SADD", worldKey, KEYS[1]) == 1 then
+    redis.call("ZINCRBY", userCountKey, 1, ARGV[1])
+end
 
-return worldKey
\ No newline at end of file
+return 1
@@ -0,0 +1,40 @@
+-- KEYS[1] = userId
+-- ARGV[1] = worldId to leave, or "" to leave whatever world the user is in
+-- returns the world the user left, or "" if the user was not in it
+
+local userKey = "user:" .. KEYS[1] .. ":world"
+local userCountKey = "worlds:user_count"
+-- the user key expires with the presence TTL, the hash keeps the world until
+-- the sweeper removes the user from it
+local currentWorld = redis.call("GET", userKey)
+if not currentWorld or currentWorld == "" then
+    currentWorld = redis.call("HGET", "presence:worlds", KEYS[1])
+end
+
+local world = ARGV[1]
+if world == "" then
+    if not currentWorld or currentWorld == "" then
+        return ""
+    end
+    world = currentWorld
+end
+
+if world == currentWorld then
+    redis.call("DEL", userKey)
+    redis.call("ZREM", "presence:last_seen", KEYS[1])
+    redis.call("HDEL", "presence:worlds", KEYS[1])
+end
+
+-- the world's set is checked even when the presence keys point elsewhere,
+-- so a user is never left behind in it
+local removed = redis.call("SREM", "world:" .. world .. ":users", KEYS[1]) == 1
+if removed then
+    if tonumber(redis.call("ZINCRBY", userCountKey, -1, world)) <= 0 then
+        redis.call("ZREM", userCountKey, world)
+    end
+end
+
+if removed or world == currentWorld then
+    return world
+end
+return ""
@@ -0,0 +1,26 @@
+-- KEYS[1] = userId
+-- ARGV[1] = window in milliseconds
+-- ARGV[2] = max creations in the window
+-- ARGV[3] = unique id of this creation
+-- returns {1, creations in the window} when the creation is allowed, or
+-- {0, creations in the window, milliseconds until a slot frees up}
+
+local creationsKey = "user:" .. KEYS[1] .. ":world_creations"
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call("ZREMRANGEBYSCORE", creationsKey, "-inf", now - window)
+local creations = redis.call("ZCARD", creationsKey)
+
+if creations >= limit then
+    local oldest = redis.call("ZRANGE", creationsKey, 0, 0, "WITHSCORES")
+    return {0, creations, tonumber(oldest[2]) + window - now}
+end
+
+redis.call("ZADD", creationsKey, now, ARGV[3])
+redis.call("PEXPIRE", creationsKey, window)
+
+return {1, creations + 1}
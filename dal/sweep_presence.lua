@@ -0,0 +1,34 @@
+-- ARGV[1] = presence TTL in seconds
+-- ARGV[2] = max number of users to expire in this run
+-- returns a flat list of userId, worldId pairs that were removed
+
+local now = tonumber(redis.call("TIME")[1])
+local cutoff = now - tonumber(ARGV[1])
+local userCountKey = "worlds:user_count"
+
+local expired = redis.call("ZRANGEBYSCORE", "presence:last_seen", "-inf", cutoff, "LIMIT", 0, ARGV[2])
+local removed = {}
+
+for _, userId in ipairs(expired) do
+    local world = redis.call("HGET", "presence:worlds", userId)
+    redis.call("ZREM", "presence:last_seen", userId)
+    redis.call("HDEL", "presence:worlds", userId)
+
+    if world then
+        local userKey = "user:" .. userId .. ":world"
+        if redis.call("GET", userKey) == world then
+            redis.call("DEL", userKey)
+        end
+
+        if redis.call("SREM", "world:" .. world .. ":users", userId) == 1 then
+            if tonumber(redis.call("ZINCRBY", userCountKey, -1, world)) <= 0 then
+                redis.call("ZREM", userCountKey, world)
+            end
+        end
+
+        table.insert(removed, userId)
+        table.insert(removed, world)
+    end
+end
+
+return removed
@@ -4,11 +4,15 @@ import (
 	"time"
 
 	"github.com/go-pg/pg"
+	"github.com/google/uuid"
 	"github.com/guilhermeCoutinho/worlds-api/models"
 )
 
 type UserDAL interface {
 	CreateUser(user *models.User) error
+	GetUserByID(id uuid.UUID) (*models.User, error)
+	GetUsersByIDs(ids []uuid.UUID) ([]models.User, error)
+	UpdateUserRole(id uuid.UUID, role models.Role) error
 }
 
 type UserDALImpl struct {
@@ -22,6 +26,45 @@ func NewUserDAL(db *pg.DB) *UserDALImpl {
 func (d *UserDALImpl) CreateUser(user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	if user.Role == "" {
+		user.Role = models.RolePlayer
+	}
 	_, err := d.db.Model(user).Insert()
 	return err
 }
+
+func (d *UserDALImpl) GetUserByID(id uuid.UUID) (*models.User, error) {
+	user := &models.User{}
+	err := d.db.Model(user).Where("id = ?", id).Select()
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (d *UserDALImpl) GetUsersByIDs(ids []uuid.UUID) ([]models.User, error) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := d.db.Model(&users).Where("id IN (?)", pg.In(ids)).Select()
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (d *UserDALImpl) UpdateUserRole(id uuid.UUID, role models.Role) error {
+	result, err := d.db.Model(&models.User{}).
+		Set("role = ?", role).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
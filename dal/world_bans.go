@@ -0,0 +1,76 @@
+package dal
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+type WorldBansDAL interface {
+	GetActiveWorldBan(worldID, userID uuid.UUID) (*models.WorldBan, error)
+	GetActiveWorldBans(worldID uuid.UUID) ([]models.WorldBan, error)
+	UpsertWorldBan(ban *models.WorldBan) error
+	DeleteWorldBan(worldID, userID uuid.UUID) error
+}
+
+type WorldBansDALImpl struct {
+	db *pg.DB
+}
+
+func NewWorldBansDAL(db *pg.DB) *WorldBansDALImpl {
+	return &WorldBansDALImpl{db: db}
+}
+
+const activeBanCondition = "(expires_at IS NULL OR expires_at > now())"
+
+func (d *WorldBansDALImpl) GetActiveWorldBan(worldID, userID uuid.UUID) (*models.WorldBan, error) {
+	ban := &models.WorldBan{}
+	err := d.db.Model(ban).
+		Where("world_id = ? AND user_id = ?", worldID, userID).
+		Where(activeBanCondition).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+func (d *WorldBansDALImpl) GetActiveWorldBans(worldID uuid.UUID) ([]models.WorldBan, error) {
+	bans := []models.WorldBan{}
+	err := d.db.Model(&bans).
+		Where("world_id = ?", worldID).
+		Where(activeBanCondition).
+		Order("created_at DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// UpsertWorldBan bans the user, replacing the reason and expiry of an
+// existing ban
+func (d *WorldBansDALImpl) UpsertWorldBan(ban *models.WorldBan) error {
+	ban.CreatedAt = time.Now()
+	_, err := d.db.Model(ban).
+		OnConflict("(world_id, user_id) DO UPDATE").
+		Set("banned_by = EXCLUDED.banned_by").
+		Set("reason = EXCLUDED.reason").
+		Set("expires_at = EXCLUDED.expires_at").
+		Set("created_at = EXCLUDED.created_at").
+		Insert()
+	return err
+}
+
+func (d *WorldBansDALImpl) DeleteWorldBan(worldID, userID uuid.UUID) error {
+	result, err := d.db.Model(&models.WorldBan{}).Where("world_id = ? AND user_id = ?", worldID, userID).Delete()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package dal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+// ErrInviteUnavailable means the invite was revoked, expired or used up
+var ErrInviteUnavailable = errors.New("invite is no longer valid")
+
+type WorldInvitesDAL interface {
+	CreateWorldInvite(invite *models.WorldInvite) error
+	GetWorldInvite(id uuid.UUID) (*models.WorldInvite, error)
+	GetOutstandingWorldInvites(worldID uuid.UUID) ([]models.WorldInvite, error)
+	GetOutstandingInvitesForUser(userID uuid.UUID) ([]models.WorldInvite, error)
+	AcceptWorldInvite(id, userID uuid.UUID, member *models.WorldMember) error
+	RevokeWorldInvite(worldID, id uuid.UUID) error
+}
+
+type WorldInvitesDALImpl struct {
+	db *pg.DB
+}
+
+func NewWorldInvitesDAL(db *pg.DB) *WorldInvitesDALImpl {
+	return &WorldInvitesDALImpl{db: db}
+}
+
+const outstandingInviteCondition = "revoked_at IS NULL AND expires_at > now() AND (max_uses = 0 OR uses < max_uses)"
+
+func (d *WorldInvitesDALImpl) CreateWorldInvite(invite *models.WorldInvite) error {
+	invite.CreatedAt = time.Now()
+	_, err := d.db.Model(invite).Insert()
+	return err
+}
+
+func (d *WorldInvitesDALImpl) GetWorldInvite(id uuid.UUID) (*models.WorldInvite, error) {
+	invite := &models.WorldInvite{}
+	err := d.db.Model(invite).Where("id = ?", id).Select()
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (d *WorldInvitesDALImpl) GetOutstandingWorldInvites(worldID uuid.UUID) ([]models.WorldInvite, error) {
+	invites := []models.WorldInvite{}
+	err := d.db.Model(&invites).
+		Where("world_id = ?", worldID).
+		Where(outstandingInviteCondition).
+		Order("created_at DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (d *WorldInvitesDALImpl) GetOutstandingInvitesForUser(userID uuid.UUID) ([]models.WorldInvite, error) {
+	invites := []models.WorldInvite{}
+	err := d.db.Model(&invites).
+		Where("invitee_id = ?", userID).
+		Where(outstandingInviteCondition).
+		Order("created_at DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// AcceptWorldInvite uses up one acceptance of the invite for the user and
+// upserts member, when not nil, in the same transaction. A user accepting
+// the same invite again doesn't use it up further, so they can retry. The
+// checks run in the UPDATE itself so concurrent accepts can't exceed max_uses.
+func (d *WorldInvitesDALImpl) AcceptWorldInvite(id, userID uuid.UUID, member *models.WorldMember) error {
+	return d.db.RunInTransaction(func(tx *pg.Tx) error {
+		result, err := tx.Model(&models.WorldInviteUse{
+			InviteID:  id,
+			UserID:    userID,
+			CreatedAt: time.Now(),
+		}).OnConflict("DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+		firstUse := result.RowsAffected() == 1
+
+		q := tx.Model(&models.WorldInvite{}).
+			Where("id = ?", id).
+			Where("revoked_at IS NULL AND expires_at > now()")
+		if firstUse {
+			q.Set("uses = uses + 1").Where("max_uses = 0 OR uses < max_uses")
+		} else {
+			q.Set("uses = uses")
+		}
+		result, err = q.Update()
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrInviteUnavailable
+		}
+
+		if member == nil {
+			return nil
+		}
+		return upsertWorldMember(tx, member)
+	})
+}
+
+func (d *WorldInvitesDALImpl) RevokeWorldInvite(worldID, id uuid.UUID) error {
+	result, err := d.db.Model(&models.WorldInvite{}).
+		Set("revoked_at = now()").
+		Where("id = ? AND world_id = ? AND revoked_at IS NULL", id, worldID).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package dal
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+type WorldMembersDAL interface {
+	GetWorldMember(worldID, userID uuid.UUID) (*models.WorldMember, error)
+	GetWorldMembers(worldID uuid.UUID) ([]models.WorldMember, error)
+	UpsertWorldMember(member *models.WorldMember) error
+	DeleteWorldMember(worldID, userID uuid.UUID) error
+}
+
+type WorldMembersDALImpl struct {
+	db *pg.DB
+}
+
+func NewWorldMembersDAL(db *pg.DB) *WorldMembersDALImpl {
+	return &WorldMembersDALImpl{db: db}
+}
+
+func (d *WorldMembersDALImpl) GetWorldMember(worldID, userID uuid.UUID) (*models.WorldMember, error) {
+	member := &models.WorldMember{}
+	err := d.db.Model(member).Where("world_id = ? AND user_id = ?", worldID, userID).Select()
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (d *WorldMembersDALImpl) GetWorldMembers(worldID uuid.UUID) ([]models.WorldMember, error) {
+	members := []models.WorldMember{}
+	err := d.db.Model(&members).Where("world_id = ?", worldID).Order("created_at ASC").Select()
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (d *WorldMembersDALImpl) UpsertWorldMember(member *models.WorldMember) error {
+	return upsertWorldMember(d.db, member)
+}
+
+func upsertWorldMember(db orm.DB, member *models.WorldMember) error {
+	member.CreatedAt = time.Now()
+	member.UpdatedAt = time.Now()
+	_, err := db.Model(member).
+		OnConflict("(world_id, user_id) DO UPDATE").
+		Set("role = EXCLUDED.role").
+		Set("updated_at = EXCLUDED.updated_at").
+		Insert()
+	return err
+}
+
+func (d *WorldMembersDALImpl) DeleteWorldMember(worldID, userID uuid.UUID) error {
+	result, err := d.db.Model(&models.WorldMember{}).Where("world_id = ? AND user_id = ?", worldID, userID).Delete()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
+	return nil
+}
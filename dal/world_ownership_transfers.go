@@ -0,0 +1,178 @@
+package dal
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+// ErrOwnershipTransferUnavailable means the offer is no longer pending, or the
+// world changed owners since it was made
+var ErrOwnershipTransferUnavailable = errors.New("ownership transfer is no longer pending")
+
+// errNoWorldQuotaLeft rolls back an accepted transfer the recipient has no quota for
+var errNoWorldQuotaLeft = errors.New("no world quota left")
+
+type WorldOwnershipTransfersDAL interface {
+	CreateOwnershipTransfer(transfer *models.WorldOwnershipTransfer) error
+	GetPendingOwnershipTransfer(worldID uuid.UUID) (*models.WorldOwnershipTransfer, error)
+	GetPendingOwnershipTransfersForUser(userID uuid.UUID) ([]models.WorldOwnershipTransfer, error)
+	ResolveOwnershipTransfer(id uuid.UUID, status models.OwnershipTransferStatus) error
+	AcceptOwnershipTransfer(transfer *models.WorldOwnershipTransfer, maxWorlds int) (*models.World, bool, error)
+}
+
+type WorldOwnershipTransfersDALImpl struct {
+	db *pg.DB
+}
+
+func NewWorldOwnershipTransfersDAL(db *pg.DB) *WorldOwnershipTransfersDALImpl {
+	return &WorldOwnershipTransfersDALImpl{db: db}
+}
+
+const pendingTransferCondition = "status = 'pending' AND expires_at > now()"
+
+// CreateOwnershipTransfer stores a new pending offer, cancelling the world's
+// previous one if there was any
+func (d *WorldOwnershipTransfersDALImpl) CreateOwnershipTransfer(transfer *models.WorldOwnershipTransfer) error {
+	transfer.Status = models.OwnershipTransferPending
+	transfer.CreatedAt = time.Now()
+	return d.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Model(&models.WorldOwnershipTransfer{}).
+			Set("status = ?", models.OwnershipTransferCancelled).
+			Set("resolved_at = now()").
+			Where("world_id = ? AND status = ?", transfer.WorldID, models.OwnershipTransferPending).
+			Update()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(transfer).Insert()
+		return err
+	})
+}
+
+func (d *WorldOwnershipTransfersDALImpl) GetPendingOwnershipTransfer(worldID uuid.UUID) (*models.WorldOwnershipTransfer, error) {
+	transfer := &models.WorldOwnershipTransfer{}
+	err := d.db.Model(transfer).
+		Where("world_id = ?", worldID).
+		Where(pendingTransferCondition).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+func (d *WorldOwnershipTransfersDALImpl) GetPendingOwnershipTransfersForUser(userID uuid.UUID) ([]models.WorldOwnershipTransfer, error) {
+	transfers := []models.WorldOwnershipTransfer{}
+	err := d.db.Model(&transfers).
+		Where("to_user_id = ?", userID).
+		Where(pendingTransferCondition).
+		Order("created_at DESC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// ResolveOwnershipTransfer declines or cancels a pending offer
+func (d *WorldOwnershipTransfersDALImpl) ResolveOwnershipTransfer(id uuid.UUID, status models.OwnershipTransferStatus) error {
+	return resolveOwnershipTransfer(d.db, id, status)
+}
+
+func resolveOwnershipTransfer(db orm.DB, id uuid.UUID, status models.OwnershipTransferStatus) error {
+	result, err := db.Model(&models.WorldOwnershipTransfer{}).
+		Set("status = ?", status).
+		Set("resolved_at = now()").
+		Where("id = ?", id).
+		Where(pendingTransferCondition).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrOwnershipTransferUnavailable
+	}
+	return nil
+}
+
+// AcceptOwnershipTransfer makes the recipient the world's owner in a new
+// version of the world. The previous owner stays on as an editor. It returns
+// false without accepting when the recipient already owns maxWorlds worlds,
+// 0 meaning unlimited.
+func (d *WorldOwnershipTransfersDALImpl) AcceptOwnershipTransfer(transfer *models.WorldOwnershipTransfer, maxWorlds int) (*models.World, bool, error) {
+	world := &models.World{}
+	err := d.db.RunInTransaction(func(tx *pg.Tx) error {
+		err := resolveOwnershipTransfer(tx, transfer.ID, models.OwnershipTransferAccepted)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(world).Where("id = ?", transfer.WorldID).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+		if world.UserID != transfer.FromUserID {
+			return ErrOwnershipTransferUnavailable
+		}
+
+		hasQuotaLeft, err := hasWorldQuotaLeft(tx, transfer.ToUserID, maxWorlds)
+		if err != nil {
+			return err
+		}
+		if !hasQuotaLeft {
+			return errNoWorldQuotaLeft
+		}
+
+		world.UserID = transfer.ToUserID
+		world.Version++
+		world.UpdatedAt = time.Now()
+		if _, err := tx.Model(world).WherePK().Update(); err != nil {
+			return err
+		}
+
+		err = upsertWorldMember(tx, &models.WorldMember{
+			WorldID: world.ID,
+			UserID:  transfer.ToUserID,
+			Role:    models.WorldMemberRoleOwner,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.Model(&models.WorldBan{}).
+			Where("world_id = ? AND user_id = ?", world.ID, transfer.ToUserID).
+			Delete()
+		if err != nil {
+			return err
+		}
+
+		previousOwner := &models.WorldMember{
+			WorldID:   world.ID,
+			UserID:    transfer.FromUserID,
+			Role:      models.WorldMemberRoleEditor,
+			CreatedAt: world.UpdatedAt,
+			UpdatedAt: world.UpdatedAt,
+		}
+		_, err = tx.Model(previousOwner).
+			OnConflict("(world_id, user_id) DO UPDATE").
+			Set("role = EXCLUDED.role").
+			Set("updated_at = EXCLUDED.updated_at").
+			Insert()
+		if err != nil {
+			return err
+		}
+
+		return insertWorldRevision(tx, world, transfer.ToUserID)
+	})
+	if err == errNoWorldQuotaLeft {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return world, true, nil
+}
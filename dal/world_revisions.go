@@ -0,0 +1,59 @@
+package dal
+
+import (
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+type WorldRevisionsDAL interface {
+	GetWorldRevisions(worldID uuid.UUID, before *int, limit int) ([]models.WorldRevision, error)
+	GetWorldRevision(worldID uuid.UUID, version int) (*models.WorldRevision, error)
+}
+
+type WorldRevisionsDALImpl struct {
+	db *pg.DB
+}
+
+func NewWorldRevisionsDAL(db *pg.DB) *WorldRevisionsDALImpl {
+	return &WorldRevisionsDALImpl{db: db}
+}
+
+// GetWorldRevisions returns up to limit revisions, newest first, optionally
+// only those older than the before version
+func (d *WorldRevisionsDALImpl) GetWorldRevisions(worldID uuid.UUID, before *int, limit int) ([]models.WorldRevision, error) {
+	revisions := []models.WorldRevision{}
+	query := d.db.Model(&revisions).Where("world_id = ?", worldID)
+	if before != nil {
+		query = query.Where("version < ?", *before)
+	}
+	err := query.Order("version DESC").Limit(limit).Select()
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (d *WorldRevisionsDALImpl) GetWorldRevision(worldID uuid.UUID, version int) (*models.WorldRevision, error) {
+	revision := &models.WorldRevision{}
+	err := d.db.Model(revision).Where("world_id = ? AND version = ?", worldID, version).Select()
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// insertWorldRevision snapshots the world as it was just written. It runs in
+// the same transaction as the write so history can't miss a version.
+func insertWorldRevision(tx *pg.Tx, world *models.World, authorID uuid.UUID) error {
+	snapshot := *world
+	snapshot.UserCount = nil
+	_, err := tx.Model(&models.WorldRevision{
+		WorldID:   world.ID,
+		Version:   world.Version,
+		AuthorID:  authorID,
+		Snapshot:  &snapshot,
+		CreatedAt: world.UpdatedAt,
+	}).Insert()
+	return err
+}
@@ -1,6 +1,7 @@
 package dal
 
 import (
+	"errors"
 	"time"
 
 	"github.com/go-pg/pg"
@@ -8,12 +9,21 @@ import (
 	"github.com/guilhermeCoutinho/worlds-api/models"
 )
 
+// ErrTransferStatusChanged means the job or world transfer left the status
+// it was read in, e.g. another worker claimed it or it was cancelled
+var ErrTransferStatusChanged = errors.New("transfer status changed")
+
 type WorldsTransferJobsDAL interface {
 	GetWorldsTransferJob(jobId uuid.UUID) (*models.WorldsTransferJob, error)
 	UpsertJob(job *models.WorldsTransferJob) error
+	UpdateJobStatus(job *models.WorldsTransferJob, from models.WorldTransferJobStatus) error
 
 	GetWorldsTransferByJob(jobId uuid.UUID) ([]models.WorldTransferJob, error)
+	GetWorldTransferJob(jobId, worldId uuid.UUID) (*models.WorldTransferJob, error)
+	GetWorldTransferJobsByStatus(status models.WorldTransferJobStatus) ([]models.WorldTransferJob, error)
+	GetStaleWorldTransferJobs(startedBefore time.Time) ([]models.WorldTransferJob, error)
 	UpsertWorldTransferJob(worldTransferJob *models.WorldTransferJob) error
+	UpdateWorldTransferJobStatus(worldTransferJob *models.WorldTransferJob, from models.WorldTransferJobStatus) error
 }
 
 type WorldsTransferJobsDALImpl struct {
@@ -42,6 +52,23 @@ func (d *WorldsTransferJobsDALImpl) UpsertJob(job *models.WorldsTransferJob) err
 	return err
 }
 
+// UpdateJobStatus saves the status and timestamps of a job that is still
+// in status from, see WorldsTransferJob.TransitionTo
+func (d *WorldsTransferJobsDALImpl) UpdateJobStatus(job *models.WorldsTransferJob, from models.WorldTransferJobStatus) error {
+	result, err := d.db.Model(job).
+		Column("status", "queued_at", "started_at", "completed_at", "failed_at", "cancelled_at", "updated_at").
+		Where("id = ?", job.ID).
+		Where("status = ?", from).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTransferStatusChanged
+	}
+	return nil
+}
+
 func (d *WorldsTransferJobsDALImpl) GetWorldsTransferByJob(jobId uuid.UUID) ([]models.WorldTransferJob, error) {
 	worlds := []models.WorldTransferJob{}
 	err := d.db.Model(&worlds).Where("job_id = ?", jobId).Select()
@@ -54,11 +81,69 @@ func (d *WorldsTransferJobsDALImpl) GetWorldsTransferByJob(jobId uuid.UUID) ([]m
 	return worlds, nil
 }
 
+func (d *WorldsTransferJobsDALImpl) GetWorldTransferJob(jobId, worldId uuid.UUID) (*models.WorldTransferJob, error) {
+	worldTransferJob := &models.WorldTransferJob{}
+	err := d.db.Model(worldTransferJob).
+		Where("job_id = ?", jobId).
+		Where("world_id = ?", worldId).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return worldTransferJob, nil
+}
+
+// GetWorldTransferJobsByStatus lists the world transfers in status across all jobs, oldest first
+func (d *WorldsTransferJobsDALImpl) GetWorldTransferJobsByStatus(status models.WorldTransferJobStatus) ([]models.WorldTransferJob, error) {
+	worlds := []models.WorldTransferJob{}
+	err := d.db.Model(&worlds).
+		Where("status = ?", status).
+		Order("created_at ASC").
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return worlds, nil
+}
+
+// GetStaleWorldTransferJobs lists the world transfers running since before
+// startedBefore, most likely left behind by a worker that stopped
+func (d *WorldsTransferJobsDALImpl) GetStaleWorldTransferJobs(startedBefore time.Time) ([]models.WorldTransferJob, error) {
+	worlds := []models.WorldTransferJob{}
+	err := d.db.Model(&worlds).
+		Where("status = ?", models.WorldTransferJobStatusRunning).
+		Where("started_at < ?", startedBefore).
+		Select()
+	if err != nil {
+		return nil, err
+	}
+	return worlds, nil
+}
+
 func (d *WorldsTransferJobsDALImpl) UpsertWorldTransferJob(worldTransferJob *models.WorldTransferJob) error {
 	_, err := d.db.Model(worldTransferJob).
-		OnConflict("(world_id) DO UPDATE").
+		OnConflict("(job_id, world_id) DO UPDATE").
 		Set("status = ?", worldTransferJob.Status).
 		Set("updated_at = ?", time.Now()).
 		Insert()
 	return err
 }
+
+// UpdateWorldTransferJobStatus saves the status, attempts, error and
+// timestamps of a world transfer that is still in status from, see
+// WorldTransferJob.TransitionTo
+func (d *WorldsTransferJobsDALImpl) UpdateWorldTransferJobStatus(worldTransferJob *models.WorldTransferJob, from models.WorldTransferJobStatus) error {
+	result, err := d.db.Model(worldTransferJob).
+		Column("status", "attempts", "error", "queued_at", "started_at", "completed_at", "failed_at", "cancelled_at", "updated_at").
+		Where("job_id = ?", worldTransferJob.JobId).
+		Where("world_id = ?", worldTransferJob.WorldID).
+		Where("status = ?", from).
+		Update()
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return ErrTransferStatusChanged
+	}
+	return nil
+}
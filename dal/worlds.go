@@ -2,24 +2,49 @@ package dal
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/guilhermeCoutinho/worlds-api/models"
 )
 
+const worldsUserCountKey = "worlds:user_count"
+
+// ErrVersionConflict means the world changed since it was read
+var ErrVersionConflict = errors.New("world version conflict")
+
 type WorldsDAL interface {
-	GetWorlds() ([]models.World, error)
+	ListWorlds(ctx context.Context, query *models.WorldsQuery) ([]models.World, error)
 	GetWorldByID(id uuid.UUID) (*models.World, error)
 	GetWorldsByOwnerID(ownerID uuid.UUID) ([]models.World, error)
-	CreateWorld(world *models.World) error
-	UpdateWorld(world *models.World) error
-	JoinWorld(ctx context.Context, userID, worldID uuid.UUID) error
+	CountWorldsByOwnerID(ownerID uuid.UUID) (int, error)
+	CreateWorld(world *models.World, maxWorlds int) (bool, error)
+	UpdateWorld(world *models.World, authorID uuid.UUID) error
+	IngestWorld(world *models.World) (*int, error)
+	SearchWorlds(text string, viewer models.WorldsViewer, limit, offset int) ([]models.WorldSearchResult, error)
+	SoftDeleteWorld(id uuid.UUID) error
+	GetDeletedWorldByID(id uuid.UUID) (*models.World, error)
+	RestoreWorld(id uuid.UUID, maxWorlds int) (bool, error)
+	PurgeDeletedWorlds(deletedBefore time.Time) (int, error)
+	ClearWorldUsers(ctx context.Context, worldID uuid.UUID) ([]uuid.UUID, error)
+	JoinWorld(ctx context.Context, userID uuid.UUID, world *models.World, presenceTTL time.Duration) (bool, error)
+	Heartbeat(ctx context.Context, userID uuid.UUID, presenceTTL time.Duration) (uuid.UUID, error)
+	SweepExpiredPresence(ctx context.Context, presenceTTL time.Duration, limit int) ([]models.WorldPresence, error)
+	LeaveWorld(ctx context.Context, userID, worldID uuid.UUID) (uuid.UUID, error)
 	GetUserCurrentWorld(ctx context.Context, userID uuid.UUID) (uuid.UUID, error)
+	GetWorldUserCount(ctx context.Context, worldID uuid.UUID) (int, error)
+	ScanWorldUsers(ctx context.Context, worldID uuid.UUID, cursor uint64, count int) ([]uuid.UUID, uint64, error)
+	ReserveWorldCreation(ctx context.Context, userID uuid.UUID, limit int, window time.Duration) (*models.CreationReservation, error)
+	CountWorldCreations(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error)
 }
 
 type WorldsDALImpl struct {
@@ -31,12 +56,274 @@ func NewWorldsDAL(db *pg.DB, redisClient *redis.Client) *WorldsDALImpl {
 	return &WorldsDALImpl{db: db, redis: redisClient}
 }
 
-func (d *WorldsDALImpl) GetWorlds() ([]models.World, error) {
-	var worlds []models.World
-	err := d.db.Model(&worlds).Select()
+var worldsSortColumns = map[models.WorldsSortField]string{
+	models.WorldsSortCreatedAt: "created_at",
+	models.WorldsSortUpdatedAt: "updated_at",
+	models.WorldsSortName:      "name",
+}
+
+// ListWorlds returns up to query.Limit+1 worlds matching the query, so callers
+// can tell whether there is a next page.
+func (d *WorldsDALImpl) ListWorlds(ctx context.Context, query *models.WorldsQuery) ([]models.World, error) {
+	if query.Sort == models.WorldsSortUserCount {
+		return d.listWorldsByUserCount(ctx, query)
+	}
+
+	column, ok := worldsSortColumns[query.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", query.Sort)
+	}
+
+	direction, comparator := "ASC", ">"
+	if query.Descending {
+		direction, comparator = "DESC", "<"
+	}
+
+	worlds := []models.World{}
+	q := d.db.Model(&worlds)
+	applyWorldsFilters(q, query)
+	if query.After != nil {
+		q.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparator), query.After.Value, query.After.ID)
+	}
+	err := q.OrderExpr(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(query.Limit + 1).
+		Select()
 	return worlds, err
 }
 
+// listWorldsByUserCount lists the worlds with players from the
+// worlds:user_count sorted set and the worlds without players from Postgres,
+// the empty ones last when descending and first when ascending. Worlds with
+// the same count are ordered by ID, like Redis orders members.
+func (d *WorldsDALImpl) listWorldsByUserCount(ctx context.Context, query *models.WorldsQuery) ([]models.World, error) {
+	afterUserCount := -1
+	if query.After != nil {
+		var err error
+		afterUserCount, err = strconv.Atoi(query.After.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user_count cursor %q: %w", query.After.Value, err)
+		}
+	}
+	limit := query.Limit + 1
+
+	if query.Descending {
+		worlds := []models.World{}
+		if afterUserCount != 0 {
+			var err error
+			worlds, err = d.listWorldsWithPlayers(ctx, query, limit)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(worlds) == limit || query.WithPlayersOnly {
+			return worlds, nil
+		}
+		empty, err := d.listWorldsWithoutPlayers(ctx, query, limit-len(worlds))
+		if err != nil {
+			return nil, err
+		}
+		return append(worlds, empty...), nil
+	}
+
+	worlds := []models.World{}
+	if afterUserCount <= 0 && !query.WithPlayersOnly {
+		var err error
+		worlds, err = d.listWorldsWithoutPlayers(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(worlds) == limit {
+			return worlds, nil
+		}
+	}
+	withPlayers, err := d.listWorldsWithPlayers(ctx, query, limit-len(worlds))
+	if err != nil {
+		return nil, err
+	}
+	return append(worlds, withPlayers...), nil
+}
+
+// listWorldsWithPlayers walks the worlds:user_count sorted set in batches and
+// hydrates each batch from Postgres, up to limit worlds.
+func (d *WorldsDALImpl) listWorldsWithPlayers(ctx context.Context, query *models.WorldsQuery, limit int) ([]models.World, error) {
+	const batchSize = 100
+
+	bounds := &redis.ZRangeBy{Min: "(0", Max: "+inf", Count: batchSize}
+	if query.After != nil && query.After.Value != "0" {
+		if query.Descending {
+			bounds.Max = query.After.Value
+		} else {
+			bounds.Min = query.After.Value
+		}
+	}
+
+	worlds := []models.World{}
+	for len(worlds) < limit {
+		var entries []redis.Z
+		var err error
+		if query.Descending {
+			entries, err = d.redis.ZRevRangeByScoreWithScores(ctx, worldsUserCountKey, bounds).Result()
+		} else {
+			entries, err = d.redis.ZRangeByScoreWithScores(ctx, worldsUserCountKey, bounds).Result()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+		bounds.Offset += int64(len(entries))
+
+		ids := []uuid.UUID{}
+		userCounts := make(map[uuid.UUID]int)
+		for _, entry := range entries {
+			member, _ := entry.Member.(string)
+			id, err := uuid.Parse(member)
+			if err != nil {
+				continue
+			}
+			if query.After != nil && isAtOrBeforeCursor(query.After, int(entry.Score), member) {
+				continue
+			}
+			ids = append(ids, id)
+			userCounts[id] = int(entry.Score)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		batch := []models.World{}
+		q := d.db.Model(&batch).Where("id IN (?)", pg.In(ids))
+		applyWorldsFilters(q, query)
+		if err := q.Select(); err != nil {
+			return nil, err
+		}
+
+		worldsByID := make(map[uuid.UUID]models.World, len(batch))
+		for _, world := range batch {
+			worldsByID[world.ID] = world
+		}
+		for _, id := range ids {
+			world, ok := worldsByID[id]
+			if !ok {
+				continue
+			}
+			userCount := userCounts[id]
+			world.UserCount = &userCount
+			worlds = append(worlds, world)
+			if len(worlds) == limit {
+				break
+			}
+		}
+	}
+
+	return worlds, nil
+}
+
+// listWorldsWithoutPlayers walks the worlds in Postgres by ID in batches and
+// keeps up to limit of those missing from the worlds:user_count sorted set
+func (d *WorldsDALImpl) listWorldsWithoutPlayers(ctx context.Context, query *models.WorldsQuery, limit int) ([]models.World, error) {
+	const batchSize = 100
+
+	direction, comparator := "ASC", ">"
+	if query.Descending {
+		direction, comparator = "DESC", "<"
+	}
+	var afterID *uuid.UUID
+	if query.After != nil && query.After.Value == "0" {
+		afterID = &query.After.ID
+	}
+
+	worlds := []models.World{}
+	for len(worlds) < limit {
+		batch := []models.World{}
+		q := d.db.Model(&batch)
+		applyWorldsFilters(q, query)
+		if afterID != nil {
+			q.Where(fmt.Sprintf("id %s ?", comparator), *afterID)
+		}
+		err := q.OrderExpr("id " + direction).Limit(batchSize).Select()
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		lastID := batch[len(batch)-1].ID
+		afterID = &lastID
+
+		pipe := d.redis.Pipeline()
+		scores := make([]*redis.FloatCmd, len(batch))
+		for i, world := range batch {
+			scores[i] = pipe.ZScore(ctx, worldsUserCountKey, world.ID.String())
+		}
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return nil, err
+		}
+
+		for i, world := range batch {
+			if scores[i].Err() == nil && scores[i].Val() > 0 {
+				continue
+			}
+			userCount := 0
+			world.UserCount = &userCount
+			worlds = append(worlds, world)
+			if len(worlds) == limit {
+				break
+			}
+		}
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	return worlds, nil
+}
+
+func isAtOrBeforeCursor(cursor *models.WorldsCursor, userCount int, member string) bool {
+	cursorUserCount, err := strconv.Atoi(cursor.Value)
+	if err != nil || userCount != cursorUserCount {
+		return false
+	}
+	if cursor.Descending {
+		return member >= cursor.ID.String()
+	}
+	return member <= cursor.ID.String()
+}
+
+func applyWorldsFilters(q *orm.Query, query *models.WorldsQuery) {
+	if condition, params := worldsVisibilityCondition(query.Viewer); condition != "" {
+		q.Where(condition, params...)
+	}
+	if query.OwnerID != nil {
+		q.Where("user_id = ?", *query.OwnerID)
+	}
+	if query.CreatedAfter != nil {
+		q.Where("created_at > ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		q.Where("created_at < ?", *query.CreatedBefore)
+	}
+	if query.NamePrefix != "" {
+		q.Where("name LIKE ?", likeEscaper.Replace(query.NamePrefix)+"%")
+	}
+}
+
+// worldsVisibilityCondition returns the WHERE condition that limits a listing
+// to the worlds the viewer may see, or "" when they may see every world
+func worldsVisibilityCondition(viewer models.WorldsViewer) (string, []interface{}) {
+	if viewer.SeesAll {
+		return "", nil
+	}
+	if viewer.UserID == nil {
+		return "visibility = ?", []interface{}{models.WorldVisibilityPublic}
+	}
+	return "(visibility = ? OR user_id = ? OR id IN (SELECT world_id FROM world_members WHERE user_id = ?))",
+		[]interface{}{models.WorldVisibilityPublic, *viewer.UserID, *viewer.UserID}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (d *WorldsDALImpl) GetWorldByID(id uuid.UUID) (*models.World, error) {
 	world := &models.World{}
 	err := d.db.Model(world).Where("id = ?", id).Select()
@@ -52,39 +339,410 @@ func (d *WorldsDALImpl) GetWorldsByOwnerID(ownerID uuid.UUID) ([]models.World, e
 	return worlds, err
 }
 
-func (d *WorldsDALImpl) CreateWorld(world *models.World) error {
+// CountWorldsByOwnerID counts the worlds a user owns, soft deleted ones excluded
+func (d *WorldsDALImpl) CountWorldsByOwnerID(ownerID uuid.UUID) (int, error) {
+	return d.db.Model(&models.World{}).Where("user_id = ?", ownerID).Count()
+}
+
+// hasWorldQuotaLeft tells if the owner owns fewer than maxWorlds worlds, 0
+// meaning unlimited. It locks the owner's row, so the check and the write
+// that follows it don't race with other ones for the same owner.
+func hasWorldQuotaLeft(tx *pg.Tx, ownerID uuid.UUID, maxWorlds int) (bool, error) {
+	if maxWorlds <= 0 {
+		return true, nil
+	}
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = ? FOR UPDATE", ownerID); err != nil {
+		return false, err
+	}
+	owned, err := tx.Model(&models.World{}).Where("user_id = ?", ownerID).Count()
+	if err != nil {
+		return false, err
+	}
+	return owned < maxWorlds, nil
+}
+
+// CreateWorld inserts the world and makes its user the owner member. It
+// returns false without inserting when the user already owns maxWorlds
+// worlds, 0 meaning unlimited.
+func (d *WorldsDALImpl) CreateWorld(world *models.World, maxWorlds int) (bool, error) {
 	world.CreatedAt = time.Now()
 	world.UpdatedAt = time.Now()
 	world.Version = 0
-	_, err := d.db.Model(world).Insert()
-	return err
+	created := false
+	err := d.db.RunInTransaction(func(tx *pg.Tx) error {
+		hasQuotaLeft, err := hasWorldQuotaLeft(tx, world.UserID, maxWorlds)
+		if err != nil || !hasQuotaLeft {
+			return err
+		}
+
+		_, err = tx.Model(world).Insert()
+		if err != nil {
+			return err
+		}
+		err = upsertWorldMember(tx, &models.WorldMember{
+			WorldID: world.ID,
+			UserID:  world.UserID,
+			Role:    models.WorldMemberRoleOwner,
+		})
+		if err != nil {
+			return err
+		}
+		if err := insertWorldRevision(tx, world, world.UserID); err != nil {
+			return err
+		}
+		if err := updateSearchVector(tx, world.ID); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
 }
 
-func (d *WorldsDALImpl) UpdateWorld(world *models.World) error {
+func (d *WorldsDALImpl) UpdateWorld(world *models.World, authorID uuid.UUID) error {
 	world.UpdatedAt = time.Now()
 	oldVersion := world.Version
 	world.Version = oldVersion + 1
-	_, err := d.db.Model(world).Where("id = ? AND version = ?", world.ID, oldVersion).Update()
+	err := d.db.RunInTransaction(func(tx *pg.Tx) error {
+		result, err := tx.Model(world).Where("id = ? AND version = ?", world.ID, oldVersion).Update()
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return ErrVersionConflict
+		}
+		if err := insertWorldRevision(tx, world, authorID); err != nil {
+			return err
+		}
+		return updateSearchVector(tx, world.ID)
+	})
+	if err != nil {
+		world.Version = oldVersion
+	}
 	return err
 }
 
-func (d *WorldsDALImpl) JoinWorld(ctx context.Context, userID, worldID uuid.UUID) error {
-	scriptPath := filepath.Join(".", "dal", "join_world.lua")
-	scriptContent, err := ioutil.ReadFile(scriptPath)
+// IngestWorld writes world as is, ID, owner and version included, creating
+// the owner when it doesn't exist and making them the world's owner member.
+// Soft deleted worlds are brought back. It
+// returns the version that was stored before, nil for new worlds, and
+// leaves the stored world untouched when that version isn't older.
+func (d *WorldsDALImpl) IngestWorld(world *models.World) (*int, error) {
+	var storedVersion *int
+	err := d.db.RunInTransaction(func(tx *pg.Tx) error {
+		stored := struct {
+			Version int
+			UserID  uuid.UUID
+		}{}
+		_, err := tx.QueryOne(&stored, "SELECT version, user_id FROM worlds WHERE id = ? FOR UPDATE", world.ID)
+		if err != nil && err != pg.ErrNoRows {
+			return err
+		}
+		if err == nil {
+			storedVersion = &stored.Version
+			if stored.Version >= world.Version {
+				return nil
+			}
+		}
+
+		now := time.Now()
+		_, err = tx.Model(&models.User{
+			ID:        world.UserID,
+			Role:      models.RolePlayer,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).OnConflict("(id) DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+
+		// the world it was forked from may not have been transferred here
+		if world.ForkedFromID != nil {
+			forkedFromCount, err := tx.Model(&models.World{}).Where("id = ?", *world.ForkedFromID).Count()
+			if err != nil {
+				return err
+			}
+			if forkedFromCount == 0 {
+				world.ForkedFromID = nil
+				world.ForkedFromVersion = nil
+			}
+		}
+
+		world.UserCount = nil
+		world.DeletedAt = time.Time{}
+		if world.CreatedAt.IsZero() {
+			world.CreatedAt = now
+		}
+		world.UpdatedAt = now
+		_, err = tx.Model(world).
+			OnConflict("(id) DO UPDATE").
+			Set("user_id = EXCLUDED.user_id").
+			Set("name = EXCLUDED.name").
+			Set("description = EXCLUDED.description").
+			Set("version = EXCLUDED.version").
+			Set("max_players = EXCLUDED.max_players").
+			Set("visibility = EXCLUDED.visibility").
+			Set("forked_from_id = EXCLUDED.forked_from_id").
+			Set("forked_from_version = EXCLUDED.forked_from_version").
+			Set("updated_at = EXCLUDED.updated_at").
+			Set("deleted_at = NULL").
+			Insert()
+		if err != nil {
+			return err
+		}
+
+		// a new owner takes over like with an ownership transfer, the previous
+		// one stays on as an editor
+		if storedVersion != nil && stored.UserID != world.UserID {
+			_, err = tx.Model(&models.WorldMember{}).
+				Set("role = ?", models.WorldMemberRoleEditor).
+				Set("updated_at = ?", now).
+				Where("world_id = ? AND user_id = ?", world.ID, stored.UserID).
+				Update()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Model(&models.WorldBan{}).
+				Where("world_id = ? AND user_id = ?", world.ID, world.UserID).
+				Delete()
+			if err != nil {
+				return err
+			}
+		}
+		err = upsertWorldMember(tx, &models.WorldMember{
+			WorldID: world.ID,
+			UserID:  world.UserID,
+			Role:    models.WorldMemberRoleOwner,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := insertWorldRevision(tx, world, world.UserID); err != nil {
+			return err
+		}
+		return updateSearchVector(tx, world.ID)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return storedVersion, nil
+}
 
-	script := redis.NewScript(string(scriptContent))
+// updateSearchVector recomputes the full-text search document of a world.
+// Names weigh more than descriptions when ranking.
+func updateSearchVector(tx *pg.Tx, worldID uuid.UUID) error {
+	_, err := tx.Exec(`
+UPDATE worlds SET search_vector =
+	setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B')
+WHERE id = ?`, worldID)
+	return err
+}
+
+// SearchWorlds returns up to limit+1 worlds matching a web-search style query
+// (quoted phrases, "or", -excluded), best matches first.
+func (d *WorldsDALImpl) SearchWorlds(text string, viewer models.WorldsViewer, limit, offset int) ([]models.WorldSearchResult, error) {
+	visibility, visibilityParams := worldsVisibilityCondition(viewer)
+	if visibility == "" {
+		visibility = "TRUE"
+	}
+
+	params := append([]interface{}{text}, visibilityParams...)
+	params = append(params, limit+1, offset)
 
-	_, err = script.Run(ctx, d.redis, []string{userID.String()}, worldID.String()).Result()
+	results := []models.WorldSearchResult{}
+	_, err := d.db.Query(&results, `
+SELECT
+	w.*,
+	ts_rank(w.search_vector, q) AS rank,
+	ts_headline('english', `+escapeHTMLSQL("coalesce(w.name, '')")+`, q, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS name_highlight,
+	ts_headline('english', `+escapeHTMLSQL("coalesce(w.description, '')")+`, q, 'MaxFragments=2, MaxWords=20, MinWords=5, StartSel=<mark>, StopSel=</mark>') AS description_snippet
+FROM worlds w, websearch_to_tsquery('english', ?) q
+WHERE w.search_vector @@ q AND w.deleted_at IS NULL AND `+visibility+`
+ORDER BY rank DESC, w.id
+LIMIT ? OFFSET ?`, params...)
+	return results, err
+}
+
+// escapeHTMLSQL wraps a text expression so it is HTML escaped by Postgres.
+// Highlights are HTML, so the user's text must not be able to add markup
+// besides the <mark> tags.
+func escapeHTMLSQL(expr string) string {
+	for _, entity := range [][2]string{{"&", "&amp;"}, {"<", "&lt;"}, {">", "&gt;"}, {`"`, "&quot;"}, {"''", "&#39;"}} {
+		expr = fmt.Sprintf("replace(%s, '%s', '%s')", expr, entity[0], entity[1])
+	}
+	return expr
+}
+
+func (d *WorldsDALImpl) SoftDeleteWorld(id uuid.UUID) error {
+	result, err := d.db.Model(&models.World{ID: id}).WherePK().Delete()
 	if err != nil {
 		return err
 	}
-
+	if result.RowsAffected() == 0 {
+		return pg.ErrNoRows
+	}
 	return nil
 }
 
+func (d *WorldsDALImpl) GetDeletedWorldByID(id uuid.UUID) (*models.World, error) {
+	world := &models.World{}
+	err := d.db.Model(world).Deleted().Where("id = ?", id).Select()
+	if err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+// RestoreWorld brings back a soft deleted world. It returns false without
+// restoring it when its owner already owns maxWorlds worlds, 0 meaning unlimited.
+func (d *WorldsDALImpl) RestoreWorld(id uuid.UUID, maxWorlds int) (bool, error) {
+	restored := false
+	err := d.db.RunInTransaction(func(tx *pg.Tx) error {
+		world := &models.World{}
+		err := tx.Model(world).Deleted().Where("id = ?", id).For("UPDATE").Select()
+		if err != nil {
+			return err
+		}
+
+		hasQuotaLeft, err := hasWorldQuotaLeft(tx, world.UserID, maxWorlds)
+		if err != nil || !hasQuotaLeft {
+			return err
+		}
+
+		_, err = tx.Model(&models.World{}).
+			Deleted().
+			Set("deleted_at = NULL").
+			Set("updated_at = ?", time.Now()).
+			Where("id = ?", id).
+			Update()
+		if err != nil {
+			return err
+		}
+		restored = true
+		return nil
+	})
+	return restored, err
+}
+
+// PurgeDeletedWorlds permanently removes worlds soft deleted before deletedBefore
+func (d *WorldsDALImpl) PurgeDeletedWorlds(deletedBefore time.Time) (int, error) {
+	result, err := d.db.Model(&models.World{}).
+		Where("deleted_at < ?", deletedBefore).
+		ForceDelete()
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// ClearWorldUsers removes every user from the world in Redis and returns them
+func (d *WorldsDALImpl) ClearWorldUsers(ctx context.Context, worldID uuid.UUID) ([]uuid.UUID, error) {
+	result, err := d.runScript(ctx, "clear_world.lua", []string{}, worldID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	members, _ := result.([]interface{})
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		userID, err := uuid.Parse(fmt.Sprint(member))
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+// JoinWorld moves the user into the world. It returns false, leaving the
+// user where they were, if the world already has MaxPlayers users.
+func (d *WorldsDALImpl) JoinWorld(ctx context.Context, userID uuid.UUID, world *models.World, presenceTTL time.Duration) (bool, error) {
+	result, err := d.runScript(
+		ctx,
+		"join_world.lua",
+		[]string{userID.String()},
+		world.ID.String(), int(presenceTTL.Seconds()), world.MaxPlayers,
+	)
+	if err != nil {
+		return false, err
+	}
+	joined, _ := result.(int64)
+	return joined == 1, nil
+}
+
+// Heartbeat extends the user's presence in their current world. It returns
+// uuid.Nil if the presence already expired and the user has to join again.
+func (d *WorldsDALImpl) Heartbeat(ctx context.Context, userID uuid.UUID, presenceTTL time.Duration) (uuid.UUID, error) {
+	result, err := d.runScript(ctx, "heartbeat.lua", []string{userID.String()}, int(presenceTTL.Seconds()))
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	currentWorld, _ := result.(string)
+	if currentWorld == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(currentWorld)
+}
+
+// SweepExpiredPresence removes up to limit users whose last heartbeat is older
+// than presenceTTL from their world's user set and returns them.
+func (d *WorldsDALImpl) SweepExpiredPresence(ctx context.Context, presenceTTL time.Duration, limit int) ([]models.WorldPresence, error) {
+	result, err := d.runScript(ctx, "sweep_presence.lua", []string{}, int(presenceTTL.Seconds()), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, _ := result.([]interface{})
+	expired := []models.WorldPresence{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		userID, err := uuid.Parse(fmt.Sprint(pairs[i]))
+		if err != nil {
+			continue
+		}
+		worldID, err := uuid.Parse(fmt.Sprint(pairs[i+1]))
+		if err != nil {
+			continue
+		}
+		expired = append(expired, models.WorldPresence{UserID: userID, WorldID: worldID})
+	}
+	return expired, nil
+}
+
+// LeaveWorld removes the user from worldID, or from whatever world they are in
+// when worldID is uuid.Nil. It returns the world that was left, or uuid.Nil if
+// the user was not in it.
+func (d *WorldsDALImpl) LeaveWorld(ctx context.Context, userID, worldID uuid.UUID) (uuid.UUID, error) {
+	worldArg := ""
+	if worldID != uuid.Nil {
+		worldArg = worldID.String()
+	}
+
+	result, err := d.runScript(ctx, "leave_world.lua", []string{userID.String()}, worldArg)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	leftWorld, _ := result.(string)
+	if leftWorld == "" {
+		return uuid.Nil, nil
+	}
+	return uuid.Parse(leftWorld)
+}
+
+func (d *WorldsDALImpl) runScript(ctx context.Context, fileName string, keys []string, args ...interface{}) (interface{}, error) {
+	scriptPath := filepath.Join(".", "dal", fileName)
+	scriptContent, err := ioutil.ReadFile(scriptPath)
+	if err != nil {
+		return nil, err
+	}
+
+	script := redis.NewScript(string(scriptContent))
+	return script.Run(ctx, d.redis, keys, args...).Result()
+}
+
 func (d *WorldsDALImpl) GetUserCurrentWorld(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
 	worldIDStr, err := d.redis.Get(ctx, "user:"+userID.String()+":world").Result()
 	if err != nil {
@@ -96,3 +754,70 @@ func (d *WorldsDALImpl) GetUserCurrentWorld(ctx context.Context, userID uuid.UUI
 
 	return uuid.Parse(worldIDStr)
 }
+
+func (d *WorldsDALImpl) GetWorldUserCount(ctx context.Context, worldID uuid.UUID) (int, error) {
+	userCount, err := d.redis.SCard(ctx, "world:"+worldID.String()+":users").Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(userCount), nil
+}
+
+// ScanWorldUsers pages through world:{id}:users with SSCAN. count is only a
+// hint to Redis, and a returned cursor of 0 means the scan is complete.
+func (d *WorldsDALImpl) ScanWorldUsers(ctx context.Context, worldID uuid.UUID, cursor uint64, count int) ([]uuid.UUID, uint64, error) {
+	members, nextCursor, err := d.redis.SScan(ctx, "world:"+worldID.String()+":users", cursor, "", int64(count)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	userIDs := make([]uuid.UUID, 0, len(members))
+	for _, member := range members {
+		userID, err := uuid.Parse(member)
+		if err != nil {
+			continue
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nextCursor, nil
+}
+
+// ReserveWorldCreation records a world creation by the user unless they
+// already created limit worlds within the sliding window
+func (d *WorldsDALImpl) ReserveWorldCreation(ctx context.Context, userID uuid.UUID, limit int, window time.Duration) (*models.CreationReservation, error) {
+	result, err := d.runScript(
+		ctx,
+		"reserve_world_creation.lua",
+		[]string{userID.String()},
+		window.Milliseconds(),
+		limit,
+		uuid.New().String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) < 2 {
+		return nil, fmt.Errorf("unexpected reserve_world_creation.lua result %v", result)
+	}
+
+	reservation := &models.CreationReservation{
+		Allowed:   values[0].(int64) == 1,
+		Creations: int(values[1].(int64)),
+	}
+	if len(values) > 2 {
+		reservation.RetryAfter = time.Duration(values[2].(int64)) * time.Millisecond
+	}
+	return reservation, nil
+}
+
+// CountWorldCreations counts the worlds the user created within the window
+func (d *WorldsDALImpl) CountWorldCreations(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	since := time.Now().Add(-window).UnixMilli()
+	creations, err := d.redis.ZCount(ctx, "user:"+userID.String()+":world_creations", strconv.FormatInt(since, 10), "+inf").Result()
+	if err != nil {
+		return 0, err
+	}
+	return int(creations), nil
+}
@@ -5,7 +5,8 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/services"
 	"github.com/guilhermeCoutinho/worlds-api/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -14,12 +15,17 @@ type UserIDCtxKeyType string
 
 const UserIDCtxKey = UserIDCtxKeyType("userID")
 
+type RoleCtxKeyType string
+
+const RoleCtxKey = RoleCtxKeyType("role")
+
 type AuthMiddleware struct {
-	logger logrus.FieldLogger
+	services *services.Services
+	logger   logrus.FieldLogger
 }
 
-func NewAuthMiddleware(logger logrus.FieldLogger) *AuthMiddleware {
-	return &AuthMiddleware{logger: logger}
+func NewAuthMiddleware(services *services.Services, logger logrus.FieldLogger) *AuthMiddleware {
+	return &AuthMiddleware{services: services, logger: logger}
 }
 
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
@@ -32,16 +38,82 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		userId := strings.TrimPrefix(token, "Bearer ")
-		userIdUUID, err := uuid.Parse(userId)
+		if !strings.HasPrefix(token, "Bearer ") {
+			logger.Error("Authorization header is not a bearer token")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		userIdUUID, err := m.services.AuthService.VerifyToken(strings.TrimPrefix(token, "Bearer "))
+		if err != nil {
+			logger.WithError(err).Error("Invalid token")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		role, err := m.services.UserService.GetUserRole(userIdUUID)
 		if err != nil {
-			logger.WithError(err).Error("Invalid user ID")
+			logger.WithError(err).Error("Failed to load user role")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		logger.WithFields(logrus.Fields{
+			"userID": userIdUUID,
+			"role":   role,
+		}).Info("User authenticated")
+		ctx := context.WithValue(r.Context(), UserIDCtxKey, userIdUUID)
+		ctx = context.WithValue(ctx, RoleCtxKey, role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ServiceAuthenticate only lets through requests bearing one of the
+// service tokens other deployments use to call the internal API
+func (m *AuthMiddleware) ServiceAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := utils.LoggerFromCtx(r.Context())
+		token := r.Header.Get("Authorization")
+		if !strings.HasPrefix(token, "Bearer ") {
+			logger.Error("No service token provided")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if err := m.services.AuthService.VerifyServiceToken(strings.TrimPrefix(token, "Bearer ")); err != nil {
+			logger.WithError(err).Error("Invalid service token")
 			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 			return
 		}
 
-		logger.WithField("userID", userIdUUID).Info("User authenticated")
-		r = r.WithContext(context.WithValue(r.Context(), UserIDCtxKey, userIdUUID))
 		next.ServeHTTP(w, r)
 	})
 }
+
+// OptionalAuthenticate authenticates requests that carry an Authorization
+// header and lets anonymous requests through, for routes whose response
+// depends on who is asking.
+func (m *AuthMiddleware) OptionalAuthenticate(next http.Handler) http.Handler {
+	authenticate := m.Authenticate(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		authenticate.ServeHTTP(w, r)
+	})
+}
+
+// RequireRole rejects requests from users below the given role. It must run after Authenticate.
+func (m *AuthMiddleware) RequireRole(role models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !RoleFromCtx(r.Context()).AtLeast(role) {
+				utils.LoggerFromCtx(r.Context()).WithField("required_role", role).Error("Insufficient role")
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
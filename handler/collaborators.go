@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+type CollaboratorsHandler struct {
+	services  *services.Services
+	validator *validator.Validate
+}
+
+func NewCollaboratorsHandler(services *services.Services, validator *validator.Validate) *CollaboratorsHandler {
+	return &CollaboratorsHandler{services: services, validator: validator}
+}
+
+func (h *CollaboratorsHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}/collaborators", ErrorHandlingMiddleware(h.HandleGetCollaborators)).Methods("GET")
+	r.Handle("/worlds/{id}/collaborators", ErrorHandlingMiddleware(h.HandleAddCollaborator)).Methods("POST")
+	r.Handle("/worlds/{id}/collaborators/{userId}", ErrorHandlingMiddleware(h.HandleRemoveCollaborator)).Methods("DELETE")
+}
+
+// writeCollaboratorsError maps collaborator service errors to HTTP responses
+func writeCollaboratorsError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, pg.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrOwnerMembershipImmutable):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+func (h *CollaboratorsHandler) HandleGetCollaborators(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	collaborators, err := h.services.CollaboratorsService.GetCollaborators(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeCollaboratorsError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(collaborators)
+}
+
+type AddCollaboratorRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Role   string `json:"role" validate:"required,oneof=editor viewer"`
+}
+
+func (h *CollaboratorsHandler) HandleAddCollaborator(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	var req AddCollaboratorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	collaborator, err := h.services.CollaboratorsService.AddCollaborator(
+		actor,
+		uuid.MustParse(params.ID),
+		uuid.MustParse(req.UserID),
+		models.WorldMemberRole(req.Role),
+	)
+	if err != nil {
+		return writeCollaboratorsError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(collaborator)
+}
+
+type CollaboratorParams struct {
+	ID     string `validate:"required,uuid"`
+	UserID string `validate:"required,uuid"`
+}
+
+func (h *CollaboratorsHandler) HandleRemoveCollaborator(w http.ResponseWriter, r *http.Request) error {
+	params := CollaboratorParams{
+		ID:     mux.Vars(r)["id"],
+		UserID: mux.Vars(r)["userId"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.CollaboratorsService.RemoveCollaborator(actor, uuid.MustParse(params.ID), uuid.MustParse(params.UserID))
+	if err != nil {
+		return writeCollaboratorsError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+// ErrorResponse is the JSON body of errors that clients need to tell apart
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, code string, err error) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Code: code, Message: err.Error()})
+	return err
+}
+
+// VersionConflictResponse is returned with 412 when an update was based on a stale world
+type VersionConflictResponse struct {
+	ErrorResponse
+	CurrentVersion int `json:"current_version"`
+}
+
+func writeVersionConflict(w http.ResponseWriter, conflict *services.WorldVersionConflictError) error {
+	w.Header().Set("ETag", worldETag(conflict.CurrentVersion))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(VersionConflictResponse{
+		ErrorResponse:  ErrorResponse{Code: "version_conflict", Message: conflict.Error()},
+		CurrentVersion: conflict.CurrentVersion,
+	})
+	return conflict
+}
+
+// writeStaleWorldVersion answers 409 to an ingest older than the stored world
+func writeStaleWorldVersion(w http.ResponseWriter, stale *services.StaleWorldVersionError) error {
+	w.Header().Set("ETag", worldETag(stale.CurrentVersion))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(VersionConflictResponse{
+		ErrorResponse:  ErrorResponse{Code: "stale_version", Message: stale.Error()},
+		CurrentVersion: stale.CurrentVersion,
+	})
+	return stale
+}
+
+// QuotaExceededResponse is returned with 403 when a user owns as many worlds as their role allows
+type QuotaExceededResponse struct {
+	ErrorResponse
+	Limit int `json:"limit"`
+}
+
+func writeWorldQuotaExceeded(w http.ResponseWriter, quotaErr *services.WorldQuotaExceededError) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(QuotaExceededResponse{
+		ErrorResponse: ErrorResponse{Code: "world_quota_exceeded", Message: quotaErr.Error()},
+		Limit:         quotaErr.Limit,
+	})
+	return quotaErr
+}
+
+// RateLimitedResponse is returned with 429 when a user created too many worlds recently
+type RateLimitedResponse struct {
+	ErrorResponse
+	Limit             int `json:"limit"`
+	WindowSeconds     int `json:"window_seconds"`
+	RetryAfterSeconds int `json:"retry_after_seconds"`
+}
+
+func writeCreationRateLimited(w http.ResponseWriter, rateErr *services.CreationRateLimitedError) error {
+	retryAfter := int(math.Ceil(rateErr.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(RateLimitedResponse{
+		ErrorResponse:     ErrorResponse{Code: "rate_limited", Message: rateErr.Error()},
+		Limit:             rateErr.Limit,
+		WindowSeconds:     int(rateErr.Window.Seconds()),
+		RetryAfterSeconds: retryAfter,
+	})
+	return rateErr
+}
+
+// writeQuotaError writes the response of quota and rate limit errors and
+// reports whether err was one of them
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *services.WorldQuotaExceededError
+	if errors.As(err, &quotaErr) {
+		writeWorldQuotaExceeded(w, quotaErr)
+		return true
+	}
+	var rateErr *services.CreationRateLimitedError
+	if errors.As(err, &rateErr) {
+		writeCreationRateLimited(w, rateErr)
+		return true
+	}
+	return false
+}
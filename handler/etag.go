@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errInvalidIfMatch = errors.New("If-Match must be a single world ETag or *")
+	// errWeakIfMatch is returned for W/ validators, If-Match only matches strong ETags (RFC 9110 13.1.1)
+	errWeakIfMatch = errors.New("If-Match does not match weak ETags")
+)
+
+// worldETag is the strong ETag of a world at the given version
+func worldETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// parseIfMatch returns the world version an If-Match header refers to, or nil
+// when the header is empty or "*" and any version matches.
+func parseIfMatch(header string) (*int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(header, "W/") {
+		return nil, errWeakIfMatch
+	}
+	unquoted, err := strconv.Unquote(header)
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+	return &version, nil
+}
+
+// writeIfMatchError answers 412 to weak ETags, which never match, and 400 to
+// malformed headers
+func writeIfMatchError(w http.ResponseWriter, err error) error {
+	if errors.Is(err, errWeakIfMatch) {
+		return writeErrorResponse(w, http.StatusPreconditionFailed, "weak_etag", err)
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+	return err
+}
@@ -9,28 +9,55 @@ import (
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
 	"github.com/guilhermeCoutinho/worlds-api/services"
 	"github.com/guilhermeCoutinho/worlds-api/utils"
 	"github.com/sirupsen/logrus"
 )
 
 type Handlers struct {
-	WorldsHandler      *WorldsHandler
-	HealthcheckHandler *HealthcheckHandler
-	UserHandler        *UserHandler
-	logger             logrus.FieldLogger
+	WorldsHandler             *WorldsHandler
+	HealthcheckHandler        *HealthcheckHandler
+	UserHandler               *UserHandler
+	CollaboratorsHandler      *CollaboratorsHandler
+	WorldRevisionsHandler     *WorldRevisionsHandler
+	InvitesHandler            *InvitesHandler
+	ModerationHandler         *ModerationHandler
+	OwnershipTransfersHandler *OwnershipTransfersHandler
+	WorldsImporterHandler     *WorldsImporterHandler
+	logger                    logrus.FieldLogger
 }
 
-// ErrorHandlingMiddleware handles errors from handlers that return errors
+// responseWriterTracker remembers whether a handler already wrote a response
+type responseWriterTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (t *responseWriterTracker) WriteHeader(statusCode int) {
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (t *responseWriterTracker) Write(b []byte) (int, error) {
+	t.wroteHeader = true
+	return t.ResponseWriter.Write(b)
+}
+
+// ErrorHandlingMiddleware handles errors from handlers that return errors.
+// Handlers that already wrote an error response keep it, otherwise a 500 is sent.
 func ErrorHandlingMiddleware(next func(http.ResponseWriter, *http.Request) error) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := next(w, r)
+		tracker := &responseWriterTracker{ResponseWriter: w}
+		err := next(tracker, r)
 		if err != nil {
 			logger := r.Context().Value(utils.LoggerCtxKey)
 			if logger != nil {
 				logger.(logrus.FieldLogger).Error(err)
 			}
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			if !tracker.wroteHeader {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
 		}
 	})
 }
@@ -40,11 +67,23 @@ func NewHandlers(services *services.Services, logger logrus.FieldLogger) *Handle
 	worldsHandler := NewWorldsHandler(services, validator)
 	healthcheckHandler := NewHealthcheckHandler()
 	userHandler := NewUserHandler(services, validator)
+	collaboratorsHandler := NewCollaboratorsHandler(services, validator)
+	worldRevisionsHandler := NewWorldRevisionsHandler(services, validator)
+	invitesHandler := NewInvitesHandler(services, validator)
+	moderationHandler := NewModerationHandler(services, validator)
+	ownershipTransfersHandler := NewOwnershipTransfersHandler(services, validator)
+	worldsImporterHandler := NewWorldsImporterHandler(services, validator)
 	return &Handlers{
-		logger:             logger,
-		WorldsHandler:      worldsHandler,
-		HealthcheckHandler: healthcheckHandler,
-		UserHandler:        userHandler,
+		logger:                    logger,
+		WorldsHandler:             worldsHandler,
+		HealthcheckHandler:        healthcheckHandler,
+		UserHandler:               userHandler,
+		CollaboratorsHandler:      collaboratorsHandler,
+		WorldRevisionsHandler:     worldRevisionsHandler,
+		InvitesHandler:            invitesHandler,
+		ModerationHandler:         moderationHandler,
+		OwnershipTransfersHandler: ownershipTransfersHandler,
+		WorldsImporterHandler:     worldsImporterHandler,
 	}
 }
 
@@ -55,6 +94,31 @@ func UserIDFromCtx(ctx context.Context) (uuid.UUID, error) {
 	return ctx.Value(UserIDCtxKey).(uuid.UUID), nil
 }
 
+func RoleFromCtx(ctx context.Context) models.Role {
+	role, ok := ctx.Value(RoleCtxKey).(models.Role)
+	if !ok {
+		return models.RolePlayer
+	}
+	return role
+}
+
+func ActorFromCtx(ctx context.Context) (services.Actor, error) {
+	userID, err := UserIDFromCtx(ctx)
+	if err != nil {
+		return services.Actor{}, err
+	}
+	return services.Actor{UserID: userID, Role: RoleFromCtx(ctx)}, nil
+}
+
+// OptionalActorFromCtx returns the authenticated actor, or nil for anonymous requests
+func OptionalActorFromCtx(ctx context.Context) *services.Actor {
+	actor, err := ActorFromCtx(ctx)
+	if err != nil {
+		return nil
+	}
+	return &actor
+}
+
 func (h *Handlers) RegisterRoutes(r *mux.Router) {
 	logger := h.logger.WithField("method", "RegisterRoutes")
 	val := reflect.ValueOf(h).Elem()
@@ -86,3 +150,35 @@ func (h *Handlers) RegisterAuthenticatedRoutes(r *mux.Router) {
 		}
 	}
 }
+
+func (h *Handlers) RegisterAdminRoutes(r *mux.Router) {
+	logger := h.logger.WithField("method", "RegisterAdminRoutes")
+	val := reflect.ValueOf(h).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		method := field.MethodByName("RegisterAdminHandler")
+		if method.IsValid() {
+			method.Call([]reflect.Value{reflect.ValueOf(r)})
+			logger.WithField("handler", field.Type().Name()).Info("Registered admin handler")
+		}
+	}
+}
+
+func (h *Handlers) RegisterInternalRoutes(r *mux.Router) {
+	logger := h.logger.WithField("method", "RegisterInternalRoutes")
+	val := reflect.ValueOf(h).Elem()
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		if field.IsNil() {
+			continue
+		}
+		method := field.MethodByName("RegisterInternalHandler")
+		if method.IsValid() {
+			method.Call([]reflect.Value{reflect.ValueOf(r)})
+			logger.WithField("handler", field.Type().Name()).Info("Registered internal handler")
+		}
+	}
+}
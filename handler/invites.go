@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+type InvitesHandler struct {
+	services  *services.Services
+	validator *validator.Validate
+}
+
+func NewInvitesHandler(services *services.Services, validator *validator.Validate) *InvitesHandler {
+	return &InvitesHandler{services: services, validator: validator}
+}
+
+func (h *InvitesHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}/invites", ErrorHandlingMiddleware(h.HandleCreateInvite)).Methods("POST")
+	r.Handle("/worlds/{id}/invites", ErrorHandlingMiddleware(h.HandleGetWorldInvites)).Methods("GET")
+	r.Handle("/worlds/{id}/invites/{inviteId}", ErrorHandlingMiddleware(h.HandleRevokeInvite)).Methods("DELETE")
+	r.Handle("/invites", ErrorHandlingMiddleware(h.HandleGetMyInvites)).Methods("GET")
+	r.Handle("/invites/{token}/accept", ErrorHandlingMiddleware(h.HandleAcceptInvite)).Methods("POST")
+}
+
+// writeInvitesError maps invite service errors to HTTP responses
+func writeInvitesError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, services.ErrWorldNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	case errors.Is(err, services.ErrInvalidInviteToken):
+		return writeErrorResponse(w, http.StatusNotFound, "invalid_invite", err)
+	case errors.Is(err, services.ErrInviteUnavailable):
+		return writeErrorResponse(w, http.StatusGone, "invite_unavailable", err)
+	case errors.Is(err, services.ErrUserBanned):
+		return writeErrorResponse(w, http.StatusForbidden, "user_banned", err)
+	case errors.Is(err, pg.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrInvalidInvite):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+type CreateInviteRequest struct {
+	UserID    string     `json:"user_id" validate:"omitempty,uuid"`
+	Role      string     `json:"role" validate:"omitempty,oneof=player viewer editor"`
+	MaxUses   int        `json:"max_uses" validate:"min=0,max=10000"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (h *InvitesHandler) HandleCreateInvite(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// the body is optional, without it a link invite with the defaults is created
+	var req CreateInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	inviteParams := services.CreateInviteParams{
+		Role:      models.WorldMemberRole(req.Role),
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.UserID != "" {
+		inviteeID := uuid.MustParse(req.UserID)
+		inviteParams.InviteeID = &inviteeID
+	}
+
+	invite, err := h.services.InvitesService.CreateInvite(actor, uuid.MustParse(params.ID), inviteParams)
+	if err != nil {
+		return writeInvitesError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(invite)
+}
+
+func (h *InvitesHandler) HandleGetWorldInvites(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	invites, err := h.services.InvitesService.GetWorldInvites(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeInvitesError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(invites)
+}
+
+type RevokeInviteParams struct {
+	ID       string `validate:"required,uuid"`
+	InviteID string `validate:"required,uuid"`
+}
+
+func (h *InvitesHandler) HandleRevokeInvite(w http.ResponseWriter, r *http.Request) error {
+	params := RevokeInviteParams{
+		ID:       mux.Vars(r)["id"],
+		InviteID: mux.Vars(r)["inviteId"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.InvitesService.RevokeInvite(actor, uuid.MustParse(params.ID), uuid.MustParse(params.InviteID))
+	if err != nil {
+		return writeInvitesError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *InvitesHandler) HandleGetMyInvites(w http.ResponseWriter, r *http.Request) error {
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	invites, err := h.services.InvitesService.GetUserInvites(actor)
+	if err != nil {
+		return writeInvitesError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(invites)
+}
+
+type AcceptInviteRequest struct {
+	Join bool `json:"join"`
+}
+
+// AcceptInviteResponse is the caller's membership after accepting. With
+// {"join": true} it also tells whether joining worked, the invite stays
+// accepted when it didn't.
+type AcceptInviteResponse struct {
+	*models.WorldMember
+	Joined    *bool          `json:"joined,omitempty"`
+	JoinError *ErrorResponse `json:"join_error,omitempty"`
+}
+
+func (h *InvitesHandler) HandleAcceptInvite(w http.ResponseWriter, r *http.Request) error {
+	token := mux.Vars(r)["token"]
+
+	var req AcceptInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	acceptance, err := h.services.InvitesService.AcceptInvite(r.Context(), actor, token, req.Join)
+	if err != nil {
+		return writeInvitesError(w, err)
+	}
+
+	response := AcceptInviteResponse{WorldMember: acceptance.Member}
+	if req.Join {
+		response.Joined = &acceptance.Joined
+	}
+	if acceptance.JoinErr != nil {
+		response.JoinError = joinErrorResponse(acceptance.JoinErr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(response)
+}
+
+// joinErrorResponse tells why joining the world after accepting an invite failed
+func joinErrorResponse(err error) *ErrorResponse {
+	code := "join_failed"
+	switch {
+	case errors.Is(err, services.ErrWorldFull):
+		code = "world_full"
+	case errors.Is(err, services.ErrUserBanned):
+		code = "user_banned"
+	case errors.Is(err, services.ErrWorldNotFound):
+		code = "world_not_found"
+	}
+	return &ErrorResponse{Code: code, Message: err.Error()}
+}
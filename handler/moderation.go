@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+type ModerationHandler struct {
+	services  *services.Services
+	validator *validator.Validate
+}
+
+func NewModerationHandler(services *services.Services, validator *validator.Validate) *ModerationHandler {
+	return &ModerationHandler{services: services, validator: validator}
+}
+
+func (h *ModerationHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}/kick/{userId}", ErrorHandlingMiddleware(h.HandleKickUser)).Methods("POST")
+	r.Handle("/worlds/{id}/bans", ErrorHandlingMiddleware(h.HandleGetBans)).Methods("GET")
+	r.Handle("/worlds/{id}/bans", ErrorHandlingMiddleware(h.HandleBanUser)).Methods("POST")
+	r.Handle("/worlds/{id}/bans/{userId}", ErrorHandlingMiddleware(h.HandleUnbanUser)).Methods("DELETE")
+}
+
+// writeModerationError maps moderation service errors to HTTP responses
+func writeModerationError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, services.ErrWorldNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	case errors.Is(err, services.ErrUserNotInWorld):
+		return writeErrorResponse(w, http.StatusNotFound, "user_not_in_world", err)
+	case errors.Is(err, pg.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrCannotModerateOwner), errors.Is(err, services.ErrInvalidBan):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+type WorldUserParams struct {
+	ID     string `validate:"required,uuid"`
+	UserID string `validate:"required,uuid"`
+}
+
+func (h *ModerationHandler) HandleKickUser(w http.ResponseWriter, r *http.Request) error {
+	params := WorldUserParams{
+		ID:     mux.Vars(r)["id"],
+		UserID: mux.Vars(r)["userId"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.ModerationService.KickUser(r.Context(), actor, uuid.MustParse(params.ID), uuid.MustParse(params.UserID))
+	if err != nil {
+		return writeModerationError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *ModerationHandler) HandleGetBans(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	bans, err := h.services.ModerationService.GetBans(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeModerationError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(bans)
+}
+
+type BanUserRequest struct {
+	UserID    string     `json:"user_id" validate:"required,uuid"`
+	Reason    string     `json:"reason" validate:"max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (h *ModerationHandler) HandleBanUser(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	var req BanUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	ban, err := h.services.ModerationService.BanUser(
+		r.Context(),
+		actor,
+		uuid.MustParse(params.ID),
+		uuid.MustParse(req.UserID),
+		req.Reason,
+		req.ExpiresAt,
+	)
+	if err != nil {
+		return writeModerationError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(ban)
+}
+
+func (h *ModerationHandler) HandleUnbanUser(w http.ResponseWriter, r *http.Request) error {
+	params := WorldUserParams{
+		ID:     mux.Vars(r)["id"],
+		UserID: mux.Vars(r)["userId"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.ModerationService.UnbanUser(actor, uuid.MustParse(params.ID), uuid.MustParse(params.UserID))
+	if err != nil {
+		return writeModerationError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
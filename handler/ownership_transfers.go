@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-pg/pg"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+type OwnershipTransfersHandler struct {
+	services  *services.Services
+	validator *validator.Validate
+}
+
+func NewOwnershipTransfersHandler(services *services.Services, validator *validator.Validate) *OwnershipTransfersHandler {
+	return &OwnershipTransfersHandler{services: services, validator: validator}
+}
+
+func (h *OwnershipTransfersHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}/ownership-transfer", ErrorHandlingMiddleware(h.HandleOfferOwnership)).Methods("POST")
+	r.Handle("/worlds/{id}/ownership-transfer", ErrorHandlingMiddleware(h.HandleGetPendingTransfer)).Methods("GET")
+	r.Handle("/worlds/{id}/ownership-transfer", ErrorHandlingMiddleware(h.HandleCancelTransfer)).Methods("DELETE")
+	r.Handle("/worlds/{id}/ownership-transfer/accept", ErrorHandlingMiddleware(h.HandleAcceptTransfer)).Methods("POST")
+	r.Handle("/worlds/{id}/ownership-transfer/decline", ErrorHandlingMiddleware(h.HandleDeclineTransfer)).Methods("POST")
+	r.Handle("/ownership-transfers", ErrorHandlingMiddleware(h.HandleGetMyTransfers)).Methods("GET")
+}
+
+// writeOwnershipTransfersError maps ownership transfer service errors to HTTP responses
+func writeOwnershipTransfersError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, services.ErrWorldNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	case errors.Is(err, services.ErrNoPendingTransfer):
+		return writeErrorResponse(w, http.StatusNotFound, "no_pending_transfer", err)
+	case errors.Is(err, pg.ErrNoRows):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, services.ErrInvalidOwnershipTransfer):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+type OfferOwnershipRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+func (h *OwnershipTransfersHandler) HandleOfferOwnership(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	var req OfferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	transfer, err := h.services.OwnershipTransfersService.OfferOwnership(actor, uuid.MustParse(params.ID), uuid.MustParse(req.UserID))
+	if err != nil {
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *OwnershipTransfersHandler) HandleGetPendingTransfer(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	transfer, err := h.services.OwnershipTransfersService.GetPendingTransfer(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(transfer)
+}
+
+func (h *OwnershipTransfersHandler) HandleCancelTransfer(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.OwnershipTransfersService.CancelTransfer(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *OwnershipTransfersHandler) HandleAcceptTransfer(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	world, err := h.services.OwnershipTransfersService.AcceptTransfer(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		if writeQuotaError(w, err) {
+			return err
+		}
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.Header().Set("ETag", worldETag(world.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(world)
+}
+
+func (h *OwnershipTransfersHandler) HandleDeclineTransfer(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.OwnershipTransfersService.DeclineTransfer(actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *OwnershipTransfersHandler) HandleGetMyTransfers(w http.ResponseWriter, r *http.Request) error {
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	transfers, err := h.services.OwnershipTransfersService.GetUserPendingTransfers(actor)
+	if err != nil {
+		return writeOwnershipTransfersError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(transfers)
+}
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/go-pg/pg"
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -24,6 +26,14 @@ func (h *UserHandler) RegisterHandler(r *mux.Router) {
 	r.Handle("/user/{id}", ErrorHandlingMiddleware(h.HandlerCreateUser)).Methods("POST")
 }
 
+func (h *UserHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/users/me/quota", ErrorHandlingMiddleware(h.HandleGetMyQuota)).Methods("GET")
+}
+
+func (h *UserHandler) RegisterAdminHandler(r *mux.Router) {
+	r.Handle("/users/{id}/role", ErrorHandlingMiddleware(h.HandleSetUserRole)).Methods("PUT")
+}
+
 type CreateUserParams struct {
 	ID string `validate:"required,uuid"`
 }
@@ -44,3 +54,70 @@ func (h *UserHandler) HandlerCreateUser(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusCreated)
 	return json.NewEncoder(w).Encode(user)
 }
+
+type SetUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=player creator moderator admin"`
+}
+
+func (h *UserHandler) HandleSetUserRole(w http.ResponseWriter, r *http.Request) error {
+	params := CreateUserParams{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	var req SetUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	user, err := h.services.UserService.SetUserRole(actor, uuid.MustParse(params.ID), models.Role(req.Role))
+	if err != nil {
+		if errors.Is(err, pg.ErrNoRows) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return err
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(user)
+}
+
+func (h *UserHandler) HandleGetMyQuota(w http.ResponseWriter, r *http.Request) error {
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	quota, err := h.services.WorldsService.GetUserQuota(r.Context(), actor)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(quota)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+// HandleGetTransferredWorld lets other environments see the version of a world
+// they are about to transfer, private worlds included
+func (h *WorldsHandler) HandleGetTransferredWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	world, err := h.services.WorldsService.GetTransferredWorld(uuid.MustParse(params.ID))
+	if errors.Is(err, services.ErrWorldNotFound) {
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("ETag", worldETag(world.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(world)
+}
+
+// HandleIngestWorld receives a world transferred from another environment.
+// It answers 201 when the world is new here and 200 otherwise.
+func (h *WorldsHandler) HandleIngestWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	world := &models.World{}
+	if err := json.NewDecoder(r.Body).Decode(world); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	result, err := h.services.WorldsService.IngestWorld(r.Context(), uuid.MustParse(params.ID), world)
+	if err != nil {
+		var stale *services.StaleWorldVersionError
+		switch {
+		case errors.As(err, &stale):
+			return writeStaleWorldVersion(w, stale)
+		case errors.Is(err, services.ErrInvalidIngestWorld):
+			return writeErrorResponse(w, http.StatusBadRequest, "invalid_world", err)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+	}
+
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	w.Header().Set("ETag", worldETag(result.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(result)
+}
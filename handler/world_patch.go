@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// maxWorldPatchBytes is far more than any patch of the world's fields needs
+const maxWorldPatchBytes = 64 << 10
+
+var errInvalidWorldPatch = errors.New("invalid world patch")
+
+// HandlePatchWorld applies an RFC 7396 merge patch, or an RFC 6902 JSON Patch
+// when sent as application/json-patch+json, to the world's editable fields.
+func (h *WorldsHandler) HandlePatchWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		contentType = mergePatchContentType
+	}
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType && contentType != "application/json" {
+		err := fmt.Errorf("unsupported patch content type %q", contentType)
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return err
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWorldPatchBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return writeErrorResponse(w, http.StatusRequestEntityTooLarge, "patch_too_large", err)
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && h.services.WorldsService.RequireIfMatch() {
+		return writeErrorResponse(w, http.StatusPreconditionRequired, "if_match_required",
+			errors.New("updates must send the world's ETag in If-Match"))
+	}
+	expectedVersion, err := parseIfMatch(ifMatch)
+	if err != nil {
+		return writeIfMatchError(w, err)
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	worldID := uuid.MustParse(params.ID)
+	world, err := h.services.WorldsService.PatchWorld(actor, worldID, expectedVersion, h.worldPatch(contentType, body))
+	if err != nil {
+		var conflict *services.WorldVersionConflictError
+		if errors.As(err, &conflict) {
+			return writeVersionConflict(w, conflict)
+		}
+		if errors.Is(err, errInvalidWorldPatch) {
+			return writeErrorResponse(w, http.StatusUnprocessableEntity, "invalid_patch", err)
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		if errors.Is(err, services.ErrWorldNotFound) {
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("ETag", worldETag(world.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(world)
+}
+
+// worldPatch applies the patch document to the world's fields and checks the
+// result with the same rules as CreateWorldRequest
+func (h *WorldsHandler) worldPatch(contentType string, patch []byte) services.WorldPatchFunc {
+	return func(current models.WorldFields) (models.WorldFields, error) {
+		document, err := json.Marshal(current)
+		if err != nil {
+			return current, err
+		}
+
+		if contentType == jsonPatchContentType {
+			operations, err := jsonpatch.DecodePatch(patch)
+			if err != nil {
+				return current, fmt.Errorf("%w: %v", errInvalidWorldPatch, err)
+			}
+			document, err = operations.Apply(document)
+			if err != nil {
+				return current, fmt.Errorf("%w: %v", errInvalidWorldPatch, err)
+			}
+		} else {
+			document, err = jsonpatch.MergePatch(document, patch)
+			if err != nil {
+				return current, fmt.Errorf("%w: %v", errInvalidWorldPatch, err)
+			}
+		}
+
+		var patched models.WorldFields
+		decoder := json.NewDecoder(bytes.NewReader(document))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&patched); err != nil {
+			return current, fmt.Errorf("%w: %v", errInvalidWorldPatch, err)
+		}
+
+		err = h.validator.Struct(CreateWorldRequest{
+			Name:        patched.Name,
+			Description: patched.Description,
+			MaxPlayers:  patched.MaxPlayers,
+			Visibility:  string(patched.Visibility),
+		})
+		if err != nil {
+			return current, fmt.Errorf("%w: %v", errInvalidWorldPatch, err)
+		}
+
+		return patched, nil
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-pg/pg"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/services"
+)
+
+type WorldRevisionsHandler struct {
+	services  *services.Services
+	validator *validator.Validate
+}
+
+func NewWorldRevisionsHandler(services *services.Services, validator *validator.Validate) *WorldRevisionsHandler {
+	return &WorldRevisionsHandler{services: services, validator: validator}
+}
+
+func (h *WorldRevisionsHandler) RegisterAuthenticatedHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}/revisions", ErrorHandlingMiddleware(h.HandleGetWorldRevisions)).Methods("GET")
+	r.Handle("/worlds/{id}/revisions/diff", ErrorHandlingMiddleware(h.HandleDiffWorldRevisions)).Methods("GET")
+	r.Handle("/worlds/{id}/revisions/{version:[0-9]+}", ErrorHandlingMiddleware(h.HandleGetWorldRevision)).Methods("GET")
+	r.Handle("/worlds/{id}/revert/{version:[0-9]+}", ErrorHandlingMiddleware(h.HandleRevertWorld)).Methods("POST")
+}
+
+// writeWorldRevisionsError maps revision service errors to HTTP responses
+func writeWorldRevisionsError(w http.ResponseWriter, err error) error {
+	var conflict *services.WorldVersionConflictError
+	switch {
+	case errors.As(err, &conflict):
+		return writeVersionConflict(w, conflict)
+	case errors.Is(err, services.ErrWorldNotFound), errors.Is(err, pg.ErrNoRows):
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	case errors.Is(err, services.ErrRevisionNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "revision_not_found", err)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
+}
+
+type WorldRevisionParams struct {
+	ID      string `validate:"required,uuid"`
+	Version string `validate:"required,numeric"`
+}
+
+type GetWorldRevisionsQueryParams struct {
+	ID     string `validate:"required,uuid"`
+	Limit  string `validate:"omitempty,numeric"`
+	Before string `validate:"omitempty,numeric"`
+}
+
+func (h *WorldRevisionsHandler) HandleGetWorldRevisions(w http.ResponseWriter, r *http.Request) error {
+	params := GetWorldRevisionsQueryParams{
+		ID:     mux.Vars(r)["id"],
+		Limit:  r.URL.Query().Get("limit"),
+		Before: r.URL.Query().Get("before"),
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	limit := 0
+	if params.Limit != "" {
+		limit, _ = strconv.Atoi(params.Limit)
+	}
+	var before *int
+	if params.Before != "" {
+		version, _ := strconv.Atoi(params.Before)
+		before = &version
+	}
+
+	page, err := h.services.WorldsService.GetWorldRevisions(actor, uuid.MustParse(params.ID), before, limit)
+	if err != nil {
+		return writeWorldRevisionsError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(page)
+}
+
+func (h *WorldRevisionsHandler) HandleGetWorldRevision(w http.ResponseWriter, r *http.Request) error {
+	params := WorldRevisionParams{
+		ID:      mux.Vars(r)["id"],
+		Version: mux.Vars(r)["version"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	version, _ := strconv.Atoi(params.Version)
+	revision, err := h.services.WorldsService.GetWorldRevision(actor, uuid.MustParse(params.ID), version)
+	if err != nil {
+		return writeWorldRevisionsError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(revision)
+}
+
+type DiffWorldRevisionsQueryParams struct {
+	ID   string `validate:"required,uuid"`
+	From string `validate:"required,numeric"`
+	To   string `validate:"required,numeric"`
+}
+
+func (h *WorldRevisionsHandler) HandleDiffWorldRevisions(w http.ResponseWriter, r *http.Request) error {
+	params := DiffWorldRevisionsQueryParams{
+		ID:   mux.Vars(r)["id"],
+		From: r.URL.Query().Get("from"),
+		To:   r.URL.Query().Get("to"),
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	from, _ := strconv.Atoi(params.From)
+	to, _ := strconv.Atoi(params.To)
+	diff, err := h.services.WorldsService.DiffWorldRevisions(actor, uuid.MustParse(params.ID), from, to)
+	if err != nil {
+		return writeWorldRevisionsError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(diff)
+}
+
+func (h *WorldRevisionsHandler) HandleRevertWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldRevisionParams{
+		ID:      mux.Vars(r)["id"],
+		Version: mux.Vars(r)["version"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	expectedVersion, err := parseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		return writeIfMatchError(w, err)
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	version, _ := strconv.Atoi(params.Version)
+	world, err := h.services.WorldsService.RevertWorld(actor, uuid.MustParse(params.ID), version, expectedVersion)
+	if err != nil {
+		return writeWorldRevisionsError(w, err)
+	}
+
+	w.Header().Set("ETag", worldETag(world.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(world)
+}
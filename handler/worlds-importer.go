@@ -2,11 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/guilhermeCoutinho/worlds-api/models"
 	"github.com/guilhermeCoutinho/worlds-api/services"
 )
 
@@ -19,14 +21,34 @@ func NewWorldsImporterHandler(services *services.Services, validator *validator.
 	return &WorldsImporterHandler{services: services, validator: validator}
 }
 
-func (h *WorldsImporterHandler) RegisterHandler(r *mux.Router) {
+func (h *WorldsImporterHandler) RegisterAuthenticatedHandler(r *mux.Router) {
 	r.Handle("/worlds/import", ErrorHandlingMiddleware(h.HandleImportWorlds)).Methods("POST")
 	r.Handle("/jobs/status/{id}", ErrorHandlingMiddleware(h.HandleGetJobStatus)).Methods("GET")
+	r.Handle("/jobs/{id}/cancel", ErrorHandlingMiddleware(h.HandleCancelJob)).Methods("POST")
+}
+
+// writeWorldsImporterError maps importer service errors to HTTP responses
+func writeWorldsImporterError(w http.ResponseWriter, err error) error {
+	switch {
+	case errors.Is(err, services.ErrUnknownEnvironment):
+		return writeErrorResponse(w, http.StatusBadRequest, "unknown_environment", err)
+	case errors.Is(err, services.ErrWorldNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+	case errors.Is(err, services.ErrTransferJobNotFound):
+		return writeErrorResponse(w, http.StatusNotFound, "job_not_found", err)
+	case errors.Is(err, models.ErrInvalidTransferTransition):
+		return writeErrorResponse(w, http.StatusConflict, "job_finished", err)
+	case errors.Is(err, services.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+	return err
 }
 
 type ImportWorldsRequest struct {
-	Worlds            []uuid.UUID `json:"worlds"`
-	TargetEnvironment string      `json:"target_environment"`
+	Worlds            []uuid.UUID `json:"worlds" validate:"required,min=1,max=100"`
+	TargetEnvironment string      `json:"target_environment" validate:"required"`
 }
 
 func (h *WorldsImporterHandler) HandleImportWorlds(w http.ResponseWriter, r *http.Request) error {
@@ -41,16 +63,15 @@ func (h *WorldsImporterHandler) HandleImportWorlds(w http.ResponseWriter, r *htt
 		return err
 	}
 
-	userID, err := UserIDFromCtx(r.Context())
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return err
 	}
 
-	response, err := h.services.WorldsImporterService.CreateImportWorldsJob(r.Context(), userID, req.Worlds, req.TargetEnvironment)
+	response, err := h.services.WorldsImporterService.CreateImportWorldsJob(r.Context(), actor, req.Worlds, req.TargetEnvironment)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return err
+		return writeWorldsImporterError(w, err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -58,18 +79,55 @@ func (h *WorldsImporterHandler) HandleImportWorlds(w http.ResponseWriter, r *htt
 	return json.NewEncoder(w).Encode(response)
 }
 
-type GetJobStatusRequest struct {
-	ID string `json:"id"`
+type JobIDParams struct {
+	ID string `validate:"required,uuid"`
 }
 
 func (h *WorldsImporterHandler) HandleGetJobStatus(w http.ResponseWriter, r *http.Request) error {
-	id := mux.Vars(r)["id"]
-	job, err := h.services.WorldsImporterService.GetAndUpdateWorldsTransferJobStatus(r.Context(), uuid.MustParse(id))
+	params := JobIDParams{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	job, err := h.services.WorldsImporterService.GetWorldsTransferJobStatus(r.Context(), actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeWorldsImporterError(w, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(job)
+}
+
+func (h *WorldsImporterHandler) HandleCancelJob(w http.ResponseWriter, r *http.Request) error {
+	params := JobIDParams{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	job, err := h.services.WorldsImporterService.CancelWorldsTransferJob(r.Context(), actor, uuid.MustParse(params.ID))
+	if err != nil {
+		return writeWorldsImporterError(w, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	return json.NewEncoder(w).Encode(job)
@@ -2,7 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
@@ -22,46 +26,217 @@ func NewWorldsHandler(services *services.Services, validator *validator.Validate
 
 func (h *WorldsHandler) RegisterHandler(r *mux.Router) {
 	r.Handle("/worlds", ErrorHandlingMiddleware(h.HandleGetWorlds)).Methods("GET")
+	r.Handle("/worlds/search", ErrorHandlingMiddleware(h.HandleSearchWorlds)).Methods("GET")
+	r.Handle("/worlds/active", ErrorHandlingMiddleware(h.HandleGetActiveWorlds)).Methods("GET")
 }
 
 func (h *WorldsHandler) RegisterAuthenticatedHandler(r *mux.Router) {
 	r.Handle("/worlds", ErrorHandlingMiddleware(h.HandleCreateWorld)).Methods("POST")
 	r.Handle("/worlds/my-current", ErrorHandlingMiddleware(h.HandleGetMyCurrentWorld)).Methods("GET")
+	r.Handle("/worlds/my-current", ErrorHandlingMiddleware(h.HandleLeaveMyCurrentWorld)).Methods("DELETE")
+	r.Handle("/worlds/my-current/heartbeat", ErrorHandlingMiddleware(h.HandleHeartbeat)).Methods("POST")
 	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleGetWorldByID)).Methods("GET")
 	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleUpdateWorld)).Methods("PUT")
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandlePatchWorld)).Methods("PATCH")
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleDeleteWorld)).Methods("DELETE")
+	r.Handle("/worlds/{id}/restore", ErrorHandlingMiddleware(h.HandleRestoreWorld)).Methods("POST")
+	r.Handle("/worlds/{id}/fork", ErrorHandlingMiddleware(h.HandleForkWorld)).Methods("POST")
 	r.Handle("/worlds/{id}/join", ErrorHandlingMiddleware(h.HandleJoinWorld)).Methods("POST")
+	r.Handle("/worlds/{id}/leave", ErrorHandlingMiddleware(h.HandleLeaveWorld)).Methods("POST")
+	r.Handle("/worlds/{id}/members", ErrorHandlingMiddleware(h.HandleGetWorldMembers)).Methods("GET")
+}
+
+func (h *WorldsHandler) RegisterAdminHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleUpdateWorld)).Methods("PUT")
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandlePatchWorld)).Methods("PATCH")
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleDeleteWorld)).Methods("DELETE")
+}
+
+func (h *WorldsHandler) RegisterInternalHandler(r *mux.Router) {
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleGetTransferredWorld)).Methods("GET")
+	r.Handle("/worlds/{id}", ErrorHandlingMiddleware(h.HandleIngestWorld)).Methods("PUT")
 }
 
 type GetWorldsQueryParams struct {
-	OwnerID string `validate:"omitempty,uuid"`
+	OwnerID       string `validate:"omitempty,uuid"`
+	Limit         string `validate:"omitempty,numeric"`
+	Cursor        string `validate:"omitempty,max=1024"`
+	Sort          string `validate:"omitempty,oneof=created_at updated_at name user_count"`
+	Order         string `validate:"omitempty,oneof=asc desc"`
+	CreatedAfter  string `validate:"omitempty"`
+	CreatedBefore string `validate:"omitempty"`
+	NamePrefix    string `validate:"omitempty,max=255"`
+}
+
+// toWorldsQuery converts already validated query params into a WorldsQuery
+func (p *GetWorldsQueryParams) toWorldsQuery() (*models.WorldsQuery, error) {
+	query := &models.WorldsQuery{
+		Sort:       models.WorldsSortField(p.Sort),
+		Descending: p.Order == "desc",
+		NamePrefix: p.NamePrefix,
+	}
+
+	// names read best A to Z, every other sort defaults to the largest first
+	if p.Order == "" && p.Sort != string(models.WorldsSortName) {
+		query.Descending = true
+	}
+
+	if p.Sort == "" && p.Order != "" {
+		query.Sort = models.WorldsSortCreatedAt
+	}
+
+	if p.OwnerID != "" {
+		ownerID := uuid.MustParse(p.OwnerID)
+		query.OwnerID = &ownerID
+	}
+
+	if p.Limit != "" {
+		limit, err := strconv.Atoi(p.Limit)
+		if err != nil {
+			return nil, err
+		}
+		query.Limit = limit
+	}
+
+	if p.CreatedAfter != "" {
+		createdAfter, err := time.Parse(time.RFC3339, p.CreatedAfter)
+		if err != nil {
+			return nil, err
+		}
+		query.CreatedAfter = &createdAfter
+	}
+
+	if p.CreatedBefore != "" {
+		createdBefore, err := time.Parse(time.RFC3339, p.CreatedBefore)
+		if err != nil {
+			return nil, err
+		}
+		query.CreatedBefore = &createdBefore
+	}
+
+	if p.Cursor != "" {
+		cursor, err := services.DecodeWorldsCursor(p.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = cursor
+	}
+
+	return query, nil
 }
 
 func (h *WorldsHandler) HandleGetWorlds(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
 	params := GetWorldsQueryParams{
-		OwnerID: r.URL.Query().Get("ownerId"),
+		OwnerID:       values.Get("ownerId"),
+		Limit:         values.Get("limit"),
+		Cursor:        values.Get("cursor"),
+		Sort:          values.Get("sort"),
+		Order:         values.Get("order"),
+		CreatedAfter:  values.Get("createdAfter"),
+		CreatedBefore: values.Get("createdBefore"),
+		NamePrefix:    values.Get("namePrefix"),
 	}
 	if err := h.validator.Struct(params); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
 
-	var worlds []models.World
-	var err error
+	query, err := params.toWorldsQuery()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
 
-	if params.OwnerID != "" {
-		ownerIDUUID := uuid.MustParse(params.OwnerID)
-		worlds, err = h.services.WorldsService.GetWorldsByOwnerID(ownerIDUUID)
-	} else {
-		worlds, err = h.services.WorldsService.GetWorlds()
+	page, err := h.services.WorldsService.ListWorlds(r.Context(), OptionalActorFromCtx(r.Context()), query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(page)
+}
+
+type GetActiveWorldsQueryParams struct {
+	Limit  string `validate:"omitempty,numeric"`
+	Cursor string `validate:"omitempty,max=1024"`
+}
+
+func (h *WorldsHandler) HandleGetActiveWorlds(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	params := GetActiveWorldsQueryParams{
+		Limit:  values.Get("limit"),
+		Cursor: values.Get("cursor"),
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	limit, _ := strconv.Atoi(params.Limit)
+
+	var after *models.WorldsCursor
+	if params.Cursor != "" {
+		cursor, err := services.DecodeWorldsCursor(params.Cursor)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		after = cursor
+	}
+
+	page, err := h.services.WorldsService.GetActiveWorlds(r.Context(), OptionalActorFromCtx(r.Context()), limit, after)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(page)
+}
+
+type SearchWorldsQueryParams struct {
+	Query  string `validate:"required,max=255"`
+	Limit  string `validate:"omitempty,numeric"`
+	Offset string `validate:"omitempty,numeric"`
+}
+
+func (h *WorldsHandler) HandleSearchWorlds(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	params := SearchWorldsQueryParams{
+		Query:  values.Get("q"),
+		Limit:  values.Get("limit"),
+		Offset: values.Get("offset"),
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// numeric allows a leading minus sign, so negative values are rejected here
+	limit, _ := strconv.Atoi(params.Limit)
+	offset, _ := strconv.Atoi(params.Offset)
+	if limit < 0 || offset < 0 {
+		err := errors.New("limit and offset must not be negative")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
 	}
 
+	page, err := h.services.WorldsService.SearchWorlds(OptionalActorFromCtx(r.Context()), params.Query, limit, offset)
 	if err != nil {
 		return err
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	return json.NewEncoder(w).Encode(worlds)
+	return json.NewEncoder(w).Encode(page)
 }
 
 type WorldIDParam struct {
@@ -77,13 +252,20 @@ func (h *WorldsHandler) HandleGetWorldByID(w http.ResponseWriter, r *http.Reques
 		return err
 	}
 
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
 	worldID := uuid.MustParse(params.ID)
-	world, err := h.services.WorldsService.GetWorldByID(worldID)
+	world, err := h.services.WorldsService.GetWorldByID(r.Context(), actor, worldID)
 	if err != nil {
 		http.Error(w, "World not found", http.StatusNotFound)
 		return err
 	}
 
+	w.Header().Set("ETag", worldETag(world.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	return json.NewEncoder(w).Encode(world)
@@ -92,6 +274,8 @@ func (h *WorldsHandler) HandleGetWorldByID(w http.ResponseWriter, r *http.Reques
 type CreateWorldRequest struct {
 	Name        string `json:"name" validate:"required,max=255,min=3"`
 	Description string `json:"description" validate:"required,max=1000,min=3"`
+	MaxPlayers  int    `json:"max_players" validate:"min=0,max=10000"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=public unlisted private"`
 }
 
 func (h *WorldsHandler) HandleCreateWorld(w http.ResponseWriter, r *http.Request) error {
@@ -106,14 +290,18 @@ func (h *WorldsHandler) HandleCreateWorld(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
-	userID, err := UserIDFromCtx(r.Context())
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return err
 	}
 
-	world, err := h.services.WorldsService.CreateWorld(userID, req.Name, req.Description)
+	world, err := h.services.WorldsService.CreateWorld(r.Context(), actor, req.Name, req.Description, req.MaxPlayers, models.WorldVisibility(req.Visibility))
 	if err != nil {
+		if writeQuotaError(w, err) {
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return err
 	}
 
@@ -123,8 +311,10 @@ func (h *WorldsHandler) HandleCreateWorld(w http.ResponseWriter, r *http.Request
 }
 
 type UpdateWorldRequest struct {
-	Name        string `json:"name" validate:"required,max=255,min=3"`
-	Description string `json:"description" validate:"required,max=1000,min=3"`
+	Name        string  `json:"name" validate:"required,max=255,min=3"`
+	Description string  `json:"description" validate:"required,max=1000,min=3"`
+	MaxPlayers  *int    `json:"max_players" validate:"omitempty,min=0,max=10000"`
+	Visibility  *string `json:"visibility" validate:"omitempty,oneof=public unlisted private"`
 }
 
 func (h *WorldsHandler) HandleUpdateWorld(w http.ResponseWriter, r *http.Request) error {
@@ -147,23 +337,169 @@ func (h *WorldsHandler) HandleUpdateWorld(w http.ResponseWriter, r *http.Request
 		return err
 	}
 
-	userID, err := UserIDFromCtx(r.Context())
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && h.services.WorldsService.RequireIfMatch() {
+		return writeErrorResponse(w, http.StatusPreconditionRequired, "if_match_required",
+			errors.New("updates must send the world's ETag in If-Match"))
+	}
+	expectedVersion, err := parseIfMatch(ifMatch)
+	if err != nil {
+		return writeIfMatchError(w, err)
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+	worldID := uuid.MustParse(params.ID)
+	world, err := h.services.WorldsService.UpdateWorld(actor, worldID, expectedVersion, req.Name, req.Description, req.MaxPlayers, (*models.WorldVisibility)(req.Visibility))
+	if err != nil {
+		var conflict *services.WorldVersionConflictError
+		if errors.As(err, &conflict) {
+			return writeVersionConflict(w, conflict)
+		}
+		if errors.Is(err, services.ErrWorldNotFound) {
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("ETag", worldETag(world.Version))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(world)
+}
+
+func (h *WorldsHandler) HandleDeleteWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return err
 	}
+
 	worldID := uuid.MustParse(params.ID)
-	world, err := h.services.WorldsService.UpdateWorld(userID, worldID, req.Name, req.Description)
+	err = h.services.WorldsService.DeleteWorld(r.Context(), actor, worldID)
+	if err != nil {
+		if errors.Is(err, services.ErrWorldNotFound) {
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h *WorldsHandler) HandleRestoreWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return err
 	}
 
+	worldID := uuid.MustParse(params.ID)
+	world, err := h.services.WorldsService.RestoreWorld(actor, worldID)
+	if err != nil {
+		if writeQuotaError(w, err) {
+			return err
+		}
+		if errors.Is(err, services.ErrWorldNotFound) {
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		}
+		if errors.Is(err, services.ErrRestoreWindowExpired) {
+			return writeErrorResponse(w, http.StatusGone, "restore_window_expired", err)
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	return json.NewEncoder(w).Encode(world)
 }
 
+type ForkWorldRequest struct {
+	Name string `json:"name" validate:"omitempty,max=255,min=3"`
+}
+
+func (h *WorldsHandler) HandleForkWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	// the body is optional, without it the fork keeps the source's name
+	var req ForkWorldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	world, err := h.services.WorldsService.ForkWorld(r.Context(), actor, uuid.MustParse(params.ID), req.Name)
+	if err != nil {
+		if writeQuotaError(w, err) {
+			return err
+		}
+		if errors.Is(err, services.ErrWorldNotFound) {
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		}
+		if errors.Is(err, services.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(world)
+}
+
 func (h *WorldsHandler) HandleJoinWorld(w http.ResponseWriter, r *http.Request) error {
 	params := WorldIDParam{
 		ID: mux.Vars(r)["id"],
@@ -173,22 +509,51 @@ func (h *WorldsHandler) HandleJoinWorld(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	userID, err := UserIDFromCtx(r.Context())
+	actor, err := ActorFromCtx(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return err
 	}
 
 	worldID := uuid.MustParse(params.ID)
-	err = h.services.WorldsService.JoinWorld(r.Context(), userID, worldID)
+	err = h.services.WorldsService.JoinWorld(r.Context(), actor, worldID)
 	if err != nil {
-		// Handle different error types
-		if err.Error() == "world not found" {
-			http.Error(w, "World not found", http.StatusNotFound)
+		switch {
+		case errors.Is(err, services.ErrWorldNotFound):
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		case errors.Is(err, services.ErrWorldFull):
+			return writeErrorResponse(w, http.StatusConflict, "world_full", err)
+		case errors.Is(err, services.ErrUserBanned):
+			return writeErrorResponse(w, http.StatusForbidden, "user_banned", err)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return err
 		}
-		if err.Error() == "user is already in this world" {
-			http.Error(w, "User is already in this world", http.StatusConflict)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func (h *WorldsHandler) HandleLeaveWorld(w http.ResponseWriter, r *http.Request) error {
+	params := WorldIDParam{
+		ID: mux.Vars(r)["id"],
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	userID, err := UserIDFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.WorldsService.LeaveWorld(r.Context(), userID, uuid.MustParse(params.ID))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotInWorld) {
+			http.Error(w, err.Error(), http.StatusConflict)
 			return err
 		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -199,6 +564,108 @@ func (h *WorldsHandler) HandleJoinWorld(w http.ResponseWriter, r *http.Request)
 	return nil
 }
 
+func (h *WorldsHandler) HandleLeaveMyCurrentWorld(w http.ResponseWriter, r *http.Request) error {
+	userID, err := UserIDFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	err = h.services.WorldsService.LeaveWorld(r.Context(), userID, uuid.Nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+type GetWorldMembersParams struct {
+	ID     string `validate:"required,uuid"`
+	Cursor string `validate:"omitempty,numeric"`
+	Limit  string `validate:"omitempty,numeric"`
+}
+
+func (h *WorldsHandler) HandleGetWorldMembers(w http.ResponseWriter, r *http.Request) error {
+	values := r.URL.Query()
+	params := GetWorldMembersParams{
+		ID:     mux.Vars(r)["id"],
+		Cursor: values.Get("cursor"),
+		Limit:  values.Get("limit"),
+	}
+	if err := h.validator.Struct(params); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return err
+	}
+
+	cursor := uint64(0)
+	if params.Cursor != "" {
+		parsedCursor, err := strconv.ParseUint(params.Cursor, 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return err
+		}
+		cursor = parsedCursor
+	}
+
+	limit, _ := strconv.Atoi(params.Limit)
+	if limit <= 0 || limit > services.MaxWorldsPageSize {
+		limit = services.DefaultWorldsPageSize
+	}
+
+	actor, err := ActorFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	page, err := h.services.WorldsService.GetWorldMembers(r.Context(), actor, uuid.MustParse(params.ID), cursor, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrWorldNotFound):
+			return writeErrorResponse(w, http.StatusNotFound, "world_not_found", err)
+		case errors.Is(err, services.ErrForbidden):
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return err
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(page)
+}
+
+func (h *WorldsHandler) HandleHeartbeat(w http.ResponseWriter, r *http.Request) error {
+	userID, err := UserIDFromCtx(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return err
+	}
+
+	worldID, ttl, err := h.services.WorldsService.Heartbeat(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrPresenceExpired) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return err
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return err
+	}
+
+	response := map[string]interface{}{
+		"world_id":   worldID.String(),
+		"expires_in": int(ttl.Seconds()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	return json.NewEncoder(w).Encode(response)
+}
+
 func (h *WorldsHandler) HandleGetMyCurrentWorld(w http.ResponseWriter, r *http.Request) error {
 	userID, err := UserIDFromCtx(r.Context())
 	if err != nil {
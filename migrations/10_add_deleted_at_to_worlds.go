@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding deleted_at to worlds")
+		_, err := db.Exec(`
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS worlds_deleted_at_idx ON worlds (deleted_at) WHERE deleted_at IS NOT NULL;
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping deleted_at from worlds")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS worlds_deleted_at_idx;
+ALTER TABLE worlds DROP COLUMN deleted_at;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
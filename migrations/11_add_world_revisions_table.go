@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_revisions")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_revisions (
+	world_id UUID REFERENCES worlds(id) ON DELETE CASCADE,
+	version INT NOT NULL,
+	author_id UUID REFERENCES users(id),
+	snapshot JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	PRIMARY KEY (world_id, version)
+);
+
+INSERT INTO world_revisions (world_id, version, author_id, snapshot, created_at)
+SELECT id, version, user_id, jsonb_build_object(
+	'id', id,
+	'user_id', user_id,
+	'name', name,
+	'description', description,
+	'version', version,
+	'max_players', max_players,
+	'created_at', created_at,
+	'updated_at', updated_at
+), updated_at
+FROM worlds
+ON CONFLICT DO NOTHING;
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_revisions")
+		_, err := db.Exec(`DROP TABLE world_revisions`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding forked_from_id and forked_from_version to worlds")
+		_, err := db.Exec(`
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS forked_from_id UUID REFERENCES worlds(id) ON DELETE SET NULL;
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS forked_from_version INT;
+
+CREATE INDEX IF NOT EXISTS worlds_forked_from_id_idx ON worlds (forked_from_id) WHERE forked_from_id IS NOT NULL;
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping forked_from_id and forked_from_version from worlds")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS worlds_forked_from_id_idx;
+ALTER TABLE worlds DROP COLUMN forked_from_version;
+ALTER TABLE worlds DROP COLUMN forked_from_id;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding visibility to worlds")
+		_, err := db.Exec(`
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS visibility VARCHAR(16) NOT NULL DEFAULT 'public';
+
+CREATE INDEX IF NOT EXISTS worlds_visibility_idx ON worlds (visibility);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping visibility from worlds")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS worlds_visibility_idx;
+ALTER TABLE worlds DROP COLUMN visibility;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
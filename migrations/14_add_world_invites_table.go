@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_invites")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_invites (
+	id UUID PRIMARY KEY,
+	world_id UUID NOT NULL REFERENCES worlds(id) ON DELETE CASCADE,
+	created_by UUID REFERENCES users(id),
+	invitee_id UUID REFERENCES users(id),
+	role VARCHAR(32) NOT NULL,
+	max_uses INT NOT NULL DEFAULT 0,
+	uses INT NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS world_invites_world_id_idx ON world_invites (world_id);
+CREATE INDEX IF NOT EXISTS world_invites_invitee_id_idx ON world_invites (invitee_id) WHERE invitee_id IS NOT NULL;
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_invites")
+		_, err := db.Exec(`DROP TABLE world_invites`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
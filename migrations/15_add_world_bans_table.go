@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_bans")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_bans (
+	world_id UUID NOT NULL REFERENCES worlds(id) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	banned_by UUID REFERENCES users(id),
+	reason TEXT,
+	expires_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	PRIMARY KEY (world_id, user_id)
+);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_bans")
+		_, err := db.Exec(`DROP TABLE world_bans`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_ownership_transfers")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_ownership_transfers (
+	id UUID PRIMARY KEY,
+	world_id UUID NOT NULL REFERENCES worlds(id) ON DELETE CASCADE,
+	from_user_id UUID NOT NULL REFERENCES users(id),
+	to_user_id UUID NOT NULL REFERENCES users(id),
+	status VARCHAR(16) NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+-- a world has at most one pending offer, a new one cancels the previous
+CREATE UNIQUE INDEX IF NOT EXISTS world_ownership_transfers_pending_idx ON world_ownership_transfers (world_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS world_ownership_transfers_to_user_id_idx ON world_ownership_transfers (to_user_id) WHERE status = 'pending';
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_ownership_transfers")
+		_, err := db.Exec(`DROP TABLE world_ownership_transfers`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+// migration 4 was meant to create these tables but creates worlds again
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating tables worlds_transfer_jobs and world_transfer_jobs")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS worlds_transfer_jobs (
+	id UUID PRIMARY KEY,
+	target_environment VARCHAR(64) NOT NULL,
+	user_id UUID REFERENCES users(id),
+	status VARCHAR(32) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS world_transfer_jobs (
+	job_id UUID NOT NULL REFERENCES worlds_transfer_jobs(id) ON DELETE CASCADE,
+	world_id UUID NOT NULL REFERENCES worlds(id) ON DELETE CASCADE,
+	world_version INT NOT NULL DEFAULT 0,
+	status VARCHAR(32) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	PRIMARY KEY (job_id, world_id)
+);
+
+CREATE INDEX IF NOT EXISTS worlds_transfer_jobs_user_id_idx ON worlds_transfer_jobs (user_id);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping tables world_transfer_jobs and worlds_transfer_jobs")
+		_, err := db.Exec(`
+DROP TABLE world_transfer_jobs;
+DROP TABLE worlds_transfer_jobs;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding lifecycle columns to worlds_transfer_jobs and world_transfer_jobs")
+		_, err := db.Exec(`
+ALTER TABLE worlds_transfer_jobs
+	ADD COLUMN IF NOT EXISTS queued_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE world_transfer_jobs
+	ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0,
+	ADD COLUMN IF NOT EXISTS error TEXT,
+	ADD COLUMN IF NOT EXISTS queued_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS failed_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE worlds_transfer_jobs SET status = 'queued', queued_at = created_at WHERE status = 'created';
+UPDATE worlds_transfer_jobs SET queued_at = created_at, completed_at = updated_at WHERE status = 'completed';
+UPDATE world_transfer_jobs SET status = 'queued', queued_at = created_at WHERE status = 'created';
+UPDATE world_transfer_jobs SET queued_at = created_at, completed_at = updated_at WHERE status = 'completed';
+
+CREATE INDEX IF NOT EXISTS world_transfer_jobs_status_idx ON world_transfer_jobs (status) WHERE status IN ('queued', 'running');
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping lifecycle columns from worlds_transfer_jobs and world_transfer_jobs")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS world_transfer_jobs_status_idx;
+
+UPDATE world_transfer_jobs SET status = 'created' WHERE status <> 'completed';
+UPDATE worlds_transfer_jobs SET status = 'created' WHERE status <> 'completed';
+
+ALTER TABLE world_transfer_jobs
+	DROP COLUMN attempts,
+	DROP COLUMN error,
+	DROP COLUMN queued_at,
+	DROP COLUMN started_at,
+	DROP COLUMN completed_at,
+	DROP COLUMN failed_at,
+	DROP COLUMN cancelled_at;
+
+ALTER TABLE worlds_transfer_jobs
+	DROP COLUMN queued_at,
+	DROP COLUMN started_at,
+	DROP COLUMN completed_at,
+	DROP COLUMN failed_at,
+	DROP COLUMN cancelled_at;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
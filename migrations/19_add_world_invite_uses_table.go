@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_invite_uses")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_invite_uses (
+	invite_id UUID NOT NULL REFERENCES world_invites(id) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	PRIMARY KEY (invite_id, user_id)
+);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_invite_uses")
+		_, err := db.Exec(`DROP TABLE world_invite_uses`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
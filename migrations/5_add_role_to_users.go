@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding role to users")
+		_, err := db.Exec(`
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'player';
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping role from users")
+		_, err := db.Exec(`ALTER TABLE users DROP COLUMN role`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating table world_members")
+		_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS world_members (
+	world_id UUID REFERENCES worlds(id) ON DELETE CASCADE,
+	user_id UUID REFERENCES users(id),
+	role VARCHAR(32) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+	PRIMARY KEY (world_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS world_members_user_id_idx ON world_members (user_id);
+
+INSERT INTO world_members (world_id, user_id, role)
+SELECT id, user_id, 'owner' FROM worlds WHERE user_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping table world_members")
+		_, err := db.Exec(`DROP TABLE world_members`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("creating worlds listing indexes")
+		_, err := db.Exec(`
+CREATE INDEX IF NOT EXISTS worlds_created_at_id_idx ON worlds (created_at, id);
+CREATE INDEX IF NOT EXISTS worlds_updated_at_id_idx ON worlds (updated_at, id);
+CREATE INDEX IF NOT EXISTS worlds_name_id_idx ON worlds (name, id);
+CREATE INDEX IF NOT EXISTS worlds_name_prefix_idx ON worlds (name text_pattern_ops);
+CREATE INDEX IF NOT EXISTS worlds_user_id_idx ON worlds (user_id);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping worlds listing indexes")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS worlds_created_at_id_idx;
+DROP INDEX IF EXISTS worlds_updated_at_id_idx;
+DROP INDEX IF EXISTS worlds_name_id_idx;
+DROP INDEX IF EXISTS worlds_name_prefix_idx;
+DROP INDEX IF EXISTS worlds_user_id_idx;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding search_vector to worlds")
+		_, err := db.Exec(`
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+UPDATE worlds SET search_vector =
+	setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B');
+
+CREATE INDEX IF NOT EXISTS worlds_search_vector_idx ON worlds USING GIN (search_vector);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping search_vector from worlds")
+		_, err := db.Exec(`
+DROP INDEX IF EXISTS worlds_search_vector_idx;
+ALTER TABLE worlds DROP COLUMN search_vector;
+`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
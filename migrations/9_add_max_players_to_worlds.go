@@ -0,0 +1,25 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-pg/migrations"
+)
+
+func init() {
+	err := migrations.Register(func(db migrations.DB) error {
+		fmt.Println("adding max_players to worlds")
+		_, err := db.Exec(`
+ALTER TABLE worlds ADD COLUMN IF NOT EXISTS max_players INT NOT NULL DEFAULT 0 CHECK (max_players >= 0);
+`)
+
+		return err
+	}, func(db migrations.DB) error {
+		fmt.Println("dropping max_players from worlds")
+		_, err := db.Exec(`ALTER TABLE worlds DROP COLUMN max_players`)
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "github.com/google/uuid"
+
+// WorldPresence is a user's live membership in a world, as tracked in Redis
+type WorldPresence struct {
+	UserID  uuid.UUID `json:"user_id"`
+	WorldID uuid.UUID `json:"world_id"`
+}
+
+// WorldMembersPage is a page of the users currently in a world
+type WorldMembersPage struct {
+	Members    []User  `json:"members"`
+	NextCursor *string `json:"next_cursor"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// CreationReservation is the outcome of checking a user's world creation rate
+type CreationReservation struct {
+	Allowed bool
+	// Creations counts the creations within the window, including this one when allowed
+	Creations int
+	// RetryAfter is how long until a creation is allowed again, only set when not allowed
+	RetryAfter time.Duration
+}
+
+// QuotaUsage compares what a user used against their limit, a Limit of 0 means unlimited
+type QuotaUsage struct {
+	Used  int `json:"used"`
+	Limit int `json:"limit"`
+}
+
+type CreationRateUsage struct {
+	QuotaUsage
+	WindowSeconds int `json:"window_seconds"`
+}
+
+// UserQuota is how much of their world quotas a user has used
+type UserQuota struct {
+	Role      Role              `json:"role"`
+	Worlds    QuotaUsage        `json:"worlds"`
+	Creations CreationRateUsage `json:"creations"`
+}
@@ -6,8 +6,35 @@ import (
 	"github.com/google/uuid"
 )
 
+type Role string
+
+const (
+	RolePlayer    Role = "player"
+	RoleCreator   Role = "creator"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// roleRank orders roles so that a higher role has every permission of the lower ones
+var roleRank = map[Role]int{
+	RolePlayer:    0,
+	RoleCreator:   1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+func (r Role) AtLeast(other Role) bool {
+	return r.IsValid() && roleRank[r] >= roleRank[other]
+}
+
 type User struct {
-	ID uuid.UUID `json:"id"`
+	ID   uuid.UUID `json:"id"`
+	Role Role      `json:"role"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -6,13 +6,163 @@ import (
 	"github.com/google/uuid"
 )
 
+type WorldVisibility string
+
+const (
+	// WorldVisibilityPublic worlds are listed and anyone can join them
+	WorldVisibilityPublic WorldVisibility = "public"
+	// WorldVisibilityUnlisted worlds can be opened and joined by ID but are not listed
+	WorldVisibilityUnlisted WorldVisibility = "unlisted"
+	// WorldVisibilityPrivate worlds are only visible to their members
+	WorldVisibilityPrivate WorldVisibility = "private"
+)
+
+func (v WorldVisibility) IsValid() bool {
+	switch v {
+	case WorldVisibilityPublic, WorldVisibilityUnlisted, WorldVisibilityPrivate:
+		return true
+	}
+	return false
+}
+
 type World struct {
 	ID          uuid.UUID `json:"id"`
 	UserID      uuid.UUID `json:"user_id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Version     int       `json:"version"`
+	// MaxPlayers caps how many users can be in the world at once, 0 means unlimited
+	MaxPlayers int             `json:"max_players" sql:",notnull"`
+	Visibility WorldVisibility `json:"visibility"`
+	// ForkedFromID and ForkedFromVersion point at the world and version this one was forked from
+	ForkedFromID      *uuid.UUID `json:"forked_from_id,omitempty"`
+	ForkedFromVersion *int       `json:"forked_from_version,omitempty"`
+
+	// UserCount is the live number of players in the world, only set when it was looked up in Redis
+	UserCount *int `json:"user_count,omitempty" sql:"-"`
 
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// DeletedAt is set when the world is soft deleted, go-pg excludes those rows from model queries
+	DeletedAt time.Time `json:"-" pg:",soft_delete"`
+}
+
+// WorldFields are the fields of a world its owner and editors can change
+type WorldFields struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	MaxPlayers  int             `json:"max_players"`
+	Visibility  WorldVisibility `json:"visibility"`
+}
+
+func (w *World) Fields() WorldFields {
+	return WorldFields{
+		Name:        w.Name,
+		Description: w.Description,
+		MaxPlayers:  w.MaxPlayers,
+		Visibility:  w.Visibility,
+	}
+}
+
+func (w *World) SetFields(fields WorldFields) {
+	w.Name = fields.Name
+	w.Description = fields.Description
+	w.MaxPlayers = fields.MaxPlayers
+	w.Visibility = fields.Visibility
+}
+
+// Diff lists the fields that differ between f and to
+func (f WorldFields) Diff(to WorldFields) []WorldFieldChange {
+	changes := []WorldFieldChange{}
+	if f.Name != to.Name {
+		changes = append(changes, WorldFieldChange{Field: "name", From: f.Name, To: to.Name})
+	}
+	if f.Description != to.Description {
+		changes = append(changes, WorldFieldChange{Field: "description", From: f.Description, To: to.Description})
+	}
+	if f.MaxPlayers != to.MaxPlayers {
+		changes = append(changes, WorldFieldChange{Field: "max_players", From: f.MaxPlayers, To: to.MaxPlayers})
+	}
+	if f.Visibility != to.Visibility {
+		changes = append(changes, WorldFieldChange{Field: "visibility", From: f.Visibility, To: to.Visibility})
+	}
+	return changes
+}
+
+// ChangedFields returns the JSON names of the fields that differ from other
+func (f WorldFields) ChangedFields(other WorldFields) []string {
+	changed := []string{}
+	for _, change := range f.Diff(other) {
+		changed = append(changed, change.Field)
+	}
+	return changed
+}
+
+type WorldsSortField string
+
+const (
+	WorldsSortCreatedAt WorldsSortField = "created_at"
+	WorldsSortUpdatedAt WorldsSortField = "updated_at"
+	WorldsSortName      WorldsSortField = "name"
+	WorldsSortUserCount WorldsSortField = "user_count"
+)
+
+// WorldsCursor marks the last world of a page. Value holds the sort column of
+// that world so the next page can continue right after it.
+type WorldsCursor struct {
+	Sort       WorldsSortField `json:"s"`
+	Descending bool            `json:"d"`
+	Value      string          `json:"v"`
+	ID         uuid.UUID       `json:"id"`
+}
+
+// WorldsViewer is who a listing is for. Anonymous viewers only see public
+// worlds, users also see the unlisted and private worlds they are members of.
+type WorldsViewer struct {
+	// UserID is nil for anonymous requests
+	UserID *uuid.UUID
+	// SeesAll skips visibility filtering, for admins
+	SeesAll bool
+}
+
+type WorldsQuery struct {
+	Viewer        WorldsViewer
+	OwnerID       *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	NamePrefix    string
+	Sort          WorldsSortField
+	Descending    bool
+	Limit         int
+	After         *WorldsCursor
+	// WithPlayersOnly leaves out worlds nobody is in when sorting by user_count
+	WithPlayersOnly bool
+}
+
+type WorldsPage struct {
+	Worlds     []World `json:"worlds"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+type WorldSearchResult struct {
+	// search queries select every worlds column, including search_vector
+	tableName struct{} `pg:",discard_unknown_columns"`
+
+	World
+	Rank               float32 `json:"rank"`
+	NameHighlight      string  `json:"name_highlight"`
+	DescriptionSnippet string  `json:"description_snippet"`
+}
+
+type WorldSearchPage struct {
+	Results    []WorldSearchResult `json:"results"`
+	NextOffset *int                `json:"next_offset"`
+}
+
+// WorldIngestResult is the state of a world written through the internal ingest API
+type WorldIngestResult struct {
+	ID      uuid.UUID `json:"id"`
+	Version int       `json:"version"`
+	// Created tells if the world didn't exist before
+	Created bool `json:"created"`
 }
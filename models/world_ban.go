@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorldBan keeps a user out of a world until ExpiresAt, or forever when
+// ExpiresAt is nil
+type WorldBan struct {
+	WorldID   uuid.UUID  `json:"world_id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	BannedBy  uuid.UUID  `json:"banned_by"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (b *WorldBan) IsActive(now time.Time) bool {
+	return b.ExpiresAt == nil || now.Before(*b.ExpiresAt)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorldInvite grants a role in a world to whoever accepts it. Invites with an
+// InviteeID can only be accepted by that user, the others work as shareable
+// links until they expire, run out of uses or are revoked.
+type WorldInvite struct {
+	ID        uuid.UUID       `json:"id"`
+	WorldID   uuid.UUID       `json:"world_id"`
+	CreatedBy uuid.UUID       `json:"created_by"`
+	InviteeID *uuid.UUID      `json:"invitee_id,omitempty"`
+	Role      WorldMemberRole `json:"role"`
+	// MaxUses is how many times the invite can be accepted, 0 means unlimited
+	MaxUses   int        `json:"max_uses" sql:",notnull"`
+	Uses      int        `json:"uses" sql:",notnull"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Token is the signed value used to accept the invite, it is not stored
+	Token string `json:"token,omitempty" sql:"-"`
+}
+
+func (i *WorldInvite) IsOutstanding(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && (i.MaxUses == 0 || i.Uses < i.MaxUses)
+}
+
+// WorldInviteUse records that a user accepted an invite, so each user spends
+// at most one of its uses
+type WorldInviteUse struct {
+	InviteID  uuid.UUID `json:"invite_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorldMemberRole string
+
+const (
+	// WorldMemberRolePlayer can see and join the world even when it is private
+	WorldMemberRolePlayer WorldMemberRole = "player"
+	WorldMemberRoleViewer WorldMemberRole = "viewer"
+	WorldMemberRoleEditor WorldMemberRole = "editor"
+	WorldMemberRoleOwner  WorldMemberRole = "owner"
+)
+
+var worldMemberRoleRank = map[WorldMemberRole]int{
+	WorldMemberRolePlayer: 0,
+	WorldMemberRoleViewer: 1,
+	WorldMemberRoleEditor: 2,
+	WorldMemberRoleOwner:  3,
+}
+
+func (r WorldMemberRole) IsValid() bool {
+	_, ok := worldMemberRoleRank[r]
+	return ok
+}
+
+func (r WorldMemberRole) AtLeast(other WorldMemberRole) bool {
+	return r.IsValid() && worldMemberRoleRank[r] >= worldMemberRoleRank[other]
+}
+
+type WorldMember struct {
+	WorldID uuid.UUID       `json:"world_id"`
+	UserID  uuid.UUID       `json:"user_id"`
+	Role    WorldMemberRole `json:"role"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
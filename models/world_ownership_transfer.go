@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OwnershipTransferStatus string
+
+const (
+	OwnershipTransferPending   OwnershipTransferStatus = "pending"
+	OwnershipTransferAccepted  OwnershipTransferStatus = "accepted"
+	OwnershipTransferDeclined  OwnershipTransferStatus = "declined"
+	OwnershipTransferCancelled OwnershipTransferStatus = "cancelled"
+)
+
+// WorldOwnershipTransfer is an offer to hand a world over to another user.
+// It stays pending until the recipient accepts or declines it, the owner
+// cancels it or it passes ExpiresAt.
+type WorldOwnershipTransfer struct {
+	ID         uuid.UUID               `json:"id"`
+	WorldID    uuid.UUID               `json:"world_id"`
+	FromUserID uuid.UUID               `json:"from_user_id"`
+	ToUserID   uuid.UUID               `json:"to_user_id"`
+	Status     OwnershipTransferStatus `json:"status"`
+	ExpiresAt  time.Time               `json:"expires_at"`
+	CreatedAt  time.Time               `json:"created_at"`
+	ResolvedAt *time.Time              `json:"resolved_at,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WorldRevision is the state of a world right after one of its versions was written
+type WorldRevision struct {
+	WorldID  uuid.UUID `json:"world_id"`
+	Version  int       `json:"version" sql:",notnull"`
+	AuthorID uuid.UUID `json:"author_id"`
+	Snapshot *World    `json:"snapshot"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorldRevisionsPage struct {
+	Revisions []WorldRevision `json:"revisions"`
+	// NextBefore is passed as before to fetch older revisions, nil on the last page
+	NextBefore *int `json:"next_before"`
+}
+
+// WorldFieldChange is one field that differs between two versions of a world
+type WorldFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+type WorldRevisionDiff struct {
+	WorldID     uuid.UUID          `json:"world_id"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Changes     []WorldFieldChange `json:"changes"`
+}
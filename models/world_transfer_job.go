@@ -1,38 +1,183 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrInvalidTransferTransition = errors.New("invalid transfer status transition")
+
 type WorldTransferJobStatus string
 
 const (
-	WorldTransferJobStatusCreated   WorldTransferJobStatus = "created"
+	WorldTransferJobStatusQueued    WorldTransferJobStatus = "queued"
+	WorldTransferJobStatusRunning   WorldTransferJobStatus = "running"
 	WorldTransferJobStatusCompleted WorldTransferJobStatus = "completed"
+	WorldTransferJobStatusFailed    WorldTransferJobStatus = "failed"
+	WorldTransferJobStatusCancelled WorldTransferJobStatus = "cancelled"
+	// WorldTransferJobStatusPartiallyCompleted is only used by jobs, when some
+	// of their worlds completed and others failed or were cancelled
+	WorldTransferJobStatusPartiallyCompleted WorldTransferJobStatus = "partially_completed"
 )
 
+// worldTransferTransitions are the statuses a world transfer can move to.
+// A running transfer that failed goes back to queued while it has attempts left.
+var worldTransferTransitions = map[WorldTransferJobStatus][]WorldTransferJobStatus{
+	WorldTransferJobStatusQueued:  {WorldTransferJobStatusRunning, WorldTransferJobStatusCancelled},
+	WorldTransferJobStatusRunning: {WorldTransferJobStatusCompleted, WorldTransferJobStatusFailed, WorldTransferJobStatusQueued},
+}
+
+// worldsTransferJobTransitions are the statuses a job can move to, following its worlds
+var worldsTransferJobTransitions = map[WorldTransferJobStatus][]WorldTransferJobStatus{
+	WorldTransferJobStatusQueued: {
+		WorldTransferJobStatusRunning,
+		WorldTransferJobStatusCancelled,
+	},
+	WorldTransferJobStatusRunning: {
+		WorldTransferJobStatusCompleted,
+		WorldTransferJobStatusFailed,
+		WorldTransferJobStatusPartiallyCompleted,
+		WorldTransferJobStatusCancelled,
+	},
+}
+
+// IsFinished tells if nothing more will happen to a transfer in this status
+func (s WorldTransferJobStatus) IsFinished() bool {
+	switch s {
+	case WorldTransferJobStatusCompleted, WorldTransferJobStatusFailed,
+		WorldTransferJobStatusCancelled, WorldTransferJobStatusPartiallyCompleted:
+		return true
+	}
+	return false
+}
+
+func canTransition(transitions map[WorldTransferJobStatus][]WorldTransferJobStatus, from, to WorldTransferJobStatus) error {
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrInvalidTransferTransition, from, to)
+}
+
+// TransferTimestamps records when a job or world transfer last entered each status
+type TransferTimestamps struct {
+	QueuedAt    *time.Time `json:"queued_at,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// Stamp sets the timestamp of status, partially completed jobs count as completed
+func (t *TransferTimestamps) Stamp(status WorldTransferJobStatus, at time.Time) {
+	switch status {
+	case WorldTransferJobStatusQueued:
+		t.QueuedAt = &at
+	case WorldTransferJobStatusRunning:
+		t.StartedAt = &at
+	case WorldTransferJobStatusCompleted, WorldTransferJobStatusPartiallyCompleted:
+		t.CompletedAt = &at
+	case WorldTransferJobStatusFailed:
+		t.FailedAt = &at
+	case WorldTransferJobStatusCancelled:
+		t.CancelledAt = &at
+	}
+}
+
 type WorldsTransferJob struct {
 	ID                uuid.UUID              `json:"id"`
 	TargetEnvironment string                 `json:"target_environment"`
 	UserID            uuid.UUID              `json:"user_id"`
 	Status            WorldTransferJobStatus `json:"status"`
-	CreatedAt         time.Time              `json:"created_at"`
-	UpdatedAt         time.Time              `json:"updated_at"`
+	TransferTimestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransitionTo moves the job to status, it fails when the move isn't allowed
+func (j *WorldsTransferJob) TransitionTo(status WorldTransferJobStatus, at time.Time) error {
+	if err := canTransition(worldsTransferJobTransitions, j.Status, status); err != nil {
+		return err
+	}
+	j.Status = status
+	j.Stamp(status, at)
+	j.UpdatedAt = at
+	return nil
 }
 
+// WorldTransferJob is one world of a WorldsTransferJob
 type WorldTransferJob struct {
 	JobId        uuid.UUID              `json:"job_id"`
 	WorldID      uuid.UUID              `json:"world_id"`
 	WorldVersion int                    `json:"world_version"`
 	Status       WorldTransferJobStatus `json:"status"`
-	CreatedAt    time.Time              `json:"created_at"`
-	UpdatedAt    time.Time              `json:"updated_at"`
+	// Attempts counts the times the world was sent to the target environment
+	Attempts int `json:"attempts" sql:",notnull"`
+	// Error is why the last attempt failed
+	Error string `json:"error,omitempty"`
+	TransferTimestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TransitionTo moves the world transfer to status, it fails when the move
+// isn't allowed. Starting counts an attempt.
+func (j *WorldTransferJob) TransitionTo(status WorldTransferJobStatus, at time.Time) error {
+	if err := canTransition(worldTransferTransitions, j.Status, status); err != nil {
+		return err
+	}
+	if status == WorldTransferJobStatusRunning {
+		j.Attempts++
+	}
+	j.Status = status
+	j.Stamp(status, at)
+	j.UpdatedAt = at
+	return nil
+}
+
+// WorldsTransferJobStatusFor is the status of a job whose worlds are in
+// statuses, nil while some of them haven't finished
+func WorldsTransferJobStatusFor(statuses []WorldTransferJobStatus) *WorldTransferJobStatus {
+	counts := make(map[WorldTransferJobStatus]int)
+	for _, status := range statuses {
+		if !status.IsFinished() {
+			return nil
+		}
+		counts[status]++
+	}
+
+	status := WorldTransferJobStatusPartiallyCompleted
+	switch len(statuses) {
+	case counts[WorldTransferJobStatusCompleted]:
+		status = WorldTransferJobStatusCompleted
+	case counts[WorldTransferJobStatusFailed]:
+		status = WorldTransferJobStatusFailed
+	case counts[WorldTransferJobStatusCancelled]:
+		status = WorldTransferJobStatusCancelled
+	}
+	if status == WorldTransferJobStatusPartiallyCompleted && counts[WorldTransferJobStatusCompleted] == 0 {
+		status = WorldTransferJobStatusFailed
+	}
+	return &status
+}
+
+// WorldTransferDTO is the progress of one world of a job
+type WorldTransferDTO struct {
+	Status   WorldTransferJobStatus `json:"status"`
+	Version  int                    `json:"version"`
+	Attempts int                    `json:"attempts"`
+	Error    string                 `json:"error,omitempty"`
+	TransferTimestamps
 }
 
 type WorldTransferJobStatusDTO struct {
 	JobId           uuid.UUID                            `json:"job_id"`
 	Status          WorldTransferJobStatus               `json:"status"`
 	StatusByWorldID map[uuid.UUID]WorldTransferJobStatus `json:"status_by_world_id"`
+	Worlds          map[uuid.UUID]WorldTransferDTO       `json:"worlds"`
+	TransferTimestamps
 }
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var allTransferStatuses = []WorldTransferJobStatus{
+	WorldTransferJobStatusQueued,
+	WorldTransferJobStatusRunning,
+	WorldTransferJobStatusCompleted,
+	WorldTransferJobStatusFailed,
+	WorldTransferJobStatusCancelled,
+	WorldTransferJobStatusPartiallyCompleted,
+}
+
+func TestWorldTransferJobTransitionTo(t *testing.T) {
+	allowed := map[WorldTransferJobStatus][]WorldTransferJobStatus{
+		WorldTransferJobStatusQueued:  {WorldTransferJobStatusRunning, WorldTransferJobStatusCancelled},
+		WorldTransferJobStatusRunning: {WorldTransferJobStatusCompleted, WorldTransferJobStatusFailed, WorldTransferJobStatusQueued},
+	}
+
+	for _, from := range allTransferStatuses {
+		for _, to := range allTransferStatuses {
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				job := &WorldTransferJob{Status: from, Attempts: 1}
+				at := time.Now()
+				err := job.TransitionTo(to, at)
+				if !containsStatus(allowed[from], to) {
+					require.ErrorIs(t, err, ErrInvalidTransferTransition)
+					require.Equal(t, from, job.Status)
+					require.Equal(t, 1, job.Attempts)
+					return
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, to, job.Status)
+				require.Equal(t, at, job.UpdatedAt)
+				// only starting counts an attempt
+				if to == WorldTransferJobStatusRunning {
+					require.Equal(t, 2, job.Attempts)
+				} else {
+					require.Equal(t, 1, job.Attempts)
+				}
+			})
+		}
+	}
+}
+
+func TestWorldsTransferJobTransitionTo(t *testing.T) {
+	allowed := map[WorldTransferJobStatus][]WorldTransferJobStatus{
+		WorldTransferJobStatusQueued: {WorldTransferJobStatusRunning, WorldTransferJobStatusCancelled},
+		WorldTransferJobStatusRunning: {
+			WorldTransferJobStatusCompleted,
+			WorldTransferJobStatusFailed,
+			WorldTransferJobStatusPartiallyCompleted,
+			WorldTransferJobStatusCancelled,
+		},
+	}
+
+	for _, from := range allTransferStatuses {
+		for _, to := range allTransferStatuses {
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				job := &WorldsTransferJob{Status: from}
+				err := job.TransitionTo(to, time.Now())
+				if !containsStatus(allowed[from], to) {
+					require.ErrorIs(t, err, ErrInvalidTransferTransition)
+					require.Equal(t, from, job.Status)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, to, job.Status)
+			})
+		}
+	}
+}
+
+func TestTransferTimestampsStamp(t *testing.T) {
+	tests := []struct {
+		status WorldTransferJobStatus
+		stamp  func(TransferTimestamps) *time.Time
+	}{
+		{status: WorldTransferJobStatusQueued, stamp: func(ts TransferTimestamps) *time.Time { return ts.QueuedAt }},
+		{status: WorldTransferJobStatusRunning, stamp: func(ts TransferTimestamps) *time.Time { return ts.StartedAt }},
+		{status: WorldTransferJobStatusCompleted, stamp: func(ts TransferTimestamps) *time.Time { return ts.CompletedAt }},
+		{status: WorldTransferJobStatusPartiallyCompleted, stamp: func(ts TransferTimestamps) *time.Time { return ts.CompletedAt }},
+		{status: WorldTransferJobStatusFailed, stamp: func(ts TransferTimestamps) *time.Time { return ts.FailedAt }},
+		{status: WorldTransferJobStatusCancelled, stamp: func(ts TransferTimestamps) *time.Time { return ts.CancelledAt }},
+	}
+	for _, test := range tests {
+		t.Run(string(test.status), func(t *testing.T) {
+			at := time.Now()
+			timestamps := TransferTimestamps{}
+			timestamps.Stamp(test.status, at)
+			require.NotNil(t, test.stamp(timestamps))
+			require.Equal(t, at, *test.stamp(timestamps))
+		})
+	}
+}
+
+func TestWorldsTransferJobStatusFor(t *testing.T) {
+	var (
+		queued    = WorldTransferJobStatusQueued
+		running   = WorldTransferJobStatusRunning
+		completed = WorldTransferJobStatusCompleted
+		failed    = WorldTransferJobStatusFailed
+		cancelled = WorldTransferJobStatusCancelled
+		partial   = WorldTransferJobStatusPartiallyCompleted
+	)
+
+	tests := []struct {
+		name     string
+		statuses []WorldTransferJobStatus
+		expected *WorldTransferJobStatus
+	}{
+		{name: "queued world", statuses: []WorldTransferJobStatus{completed, queued}, expected: nil},
+		{name: "running world", statuses: []WorldTransferJobStatus{running, failed}, expected: nil},
+		{name: "all completed", statuses: []WorldTransferJobStatus{completed, completed}, expected: &completed},
+		{name: "all failed", statuses: []WorldTransferJobStatus{failed, failed}, expected: &failed},
+		{name: "all cancelled", statuses: []WorldTransferJobStatus{cancelled}, expected: &cancelled},
+		{name: "some completed and some failed", statuses: []WorldTransferJobStatus{completed, failed}, expected: &partial},
+		{name: "some completed and some cancelled", statuses: []WorldTransferJobStatus{cancelled, completed}, expected: &partial},
+		{name: "none completed", statuses: []WorldTransferJobStatus{failed, cancelled}, expected: &failed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, WorldsTransferJobStatusFor(test.statuses))
+		})
+	}
+}
+
+func containsStatus(statuses []WorldTransferJobStatus, status WorldTransferJobStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
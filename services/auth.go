@@ -0,0 +1,125 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type AuthService struct {
+	logger     logrus.FieldLogger
+	devMode    bool
+	hmacSecret []byte
+	keySet     *JWKSKeySet
+	parser     *jwt.Parser
+	// serviceTokens authenticate other deployments on the internal API
+	serviceTokens [][]byte
+}
+
+func NewAuthService(config *viper.Viper, logger logrus.FieldLogger) (*AuthService, error) {
+	config.SetDefault("auth.leeway", "30s")
+	config.SetDefault("auth.jwks_reload_interval", "1m")
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+		}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.GetDuration("auth.leeway")),
+	}
+	if issuer := config.GetString("auth.issuer"); issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience := config.GetString("auth.audience"); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	service := &AuthService{
+		logger:     logger,
+		devMode:    config.GetBool("auth.dev_mode"),
+		hmacSecret: []byte(config.GetString("auth.hmac_secret")),
+		parser:     jwt.NewParser(options...),
+	}
+
+	for _, token := range strings.Split(config.GetString("auth.service_tokens"), ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			service.serviceTokens = append(service.serviceTokens, []byte(token))
+		}
+	}
+
+	if jwksPath := config.GetString("auth.jwks_path"); jwksPath != "" {
+		keySet, err := NewJWKSKeySet(jwksPath, config.GetDuration("auth.jwks_reload_interval"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWKS from %s: %w", jwksPath, err)
+		}
+		service.keySet = keySet
+	}
+
+	if service.devMode {
+		logger.Warn("Auth dev mode is enabled, raw user IDs are accepted as bearer tokens")
+	}
+
+	return service, nil
+}
+
+// VerifyToken validates a bearer token and returns the user ID from its sub claim.
+// In dev mode a raw user UUID is also accepted as the token.
+func (s *AuthService) VerifyToken(tokenString string) (uuid.UUID, error) {
+	if s.devMode {
+		if userID, err := uuid.Parse(tokenString); err == nil {
+			return userID, nil
+		}
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err := s.parser.ParseWithClaims(tokenString, claims, s.keyFunc)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: sub claim is not a valid user ID", ErrInvalidToken)
+	}
+
+	return userID, nil
+}
+
+// VerifyServiceToken checks a bearer token against auth.service_tokens.
+// Without service tokens configured the internal API is closed.
+func (s *AuthService) VerifyServiceToken(tokenString string) error {
+	for _, token := range s.serviceTokens {
+		if subtle.ConstantTimeCompare([]byte(tokenString), token) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: unknown service token", ErrInvalidToken)
+}
+
+func (s *AuthService) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(s.hmacSecret) == 0 {
+			return nil, errors.New("HMAC signed tokens are not enabled")
+		}
+		return s.hmacSecret, nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		if s.keySet == nil {
+			return nil, errors.New("no JWKS configured")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return s.keySet.Key(kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrOwnerMembershipImmutable = errors.New("the owner's membership cannot be changed")
+
+type CollaboratorsService struct {
+	dal    *dal.DAL
+	logger logrus.FieldLogger
+	policy *Policy
+}
+
+func NewCollaboratorsService(dal *dal.DAL, logger logrus.FieldLogger, policy *Policy) *CollaboratorsService {
+	return &CollaboratorsService{dal: dal, logger: logger, policy: policy}
+}
+
+func (s *CollaboratorsService) GetCollaborators(actor Actor, worldID uuid.UUID) ([]models.WorldMember, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanViewCollaborators(actor, world); err != nil {
+		return nil, err
+	}
+
+	return s.dal.WorldMembersDAL.GetWorldMembers(worldID)
+}
+
+func (s *CollaboratorsService) AddCollaborator(actor Actor, worldID, userID uuid.UUID, role models.WorldMemberRole) (*models.WorldMember, error) {
+	if !role.IsValid() || role == models.WorldMemberRoleOwner {
+		return nil, ErrInvalidRole
+	}
+
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanManageCollaborators(actor, world); err != nil {
+		return nil, err
+	}
+
+	if world.UserID == userID {
+		return nil, ErrOwnerMembershipImmutable
+	}
+
+	if _, err := s.dal.UserDAL.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	member := &models.WorldMember{
+		WorldID: worldID,
+		UserID:  userID,
+		Role:    role,
+	}
+	err = s.dal.WorldMembersDAL.UpsertWorldMember(member)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id": worldID,
+		"user_id":  userID,
+		"role":     role,
+	}).Info("Collaborator added")
+
+	return member, nil
+}
+
+func (s *CollaboratorsService) RemoveCollaborator(actor Actor, worldID, userID uuid.UUID) error {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.CanManageCollaborators(actor, world); err != nil {
+		return err
+	}
+
+	if world.UserID == userID {
+		return ErrOwnerMembershipImmutable
+	}
+
+	return s.dal.WorldMembersDAL.DeleteWorldMember(worldID, userID)
+}
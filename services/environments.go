@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var ErrUnknownEnvironment = errors.New("unknown environment")
+
+// Environment is another deployment of the worlds API that worlds can be
+// transferred to
+type Environment struct {
+	Name    string
+	BaseURL string
+	// Token is sent as a bearer token on every request to the environment
+	Token string
+	// ServiceToken replaces Token on calls to the environment's internal API,
+	// it must be listed in the environment's auth.service_tokens
+	ServiceToken string
+	// Timeout bounds each attempt, retries get a fresh timeout
+	Timeout        time.Duration
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// EnvironmentRegistry holds the environments listed in environments.names.
+// Each one is configured with environments.<name>.url, .token and
+// .service_token, and can override the shared timeout, max_retries,
+// initial_backoff and max_backoff.
+type EnvironmentRegistry struct {
+	environments map[string]*Environment
+}
+
+// environment names end up in environment variable names, e.g. ENVIRONMENTS_STAGING_URL
+var environmentNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+func NewEnvironmentRegistry(config *viper.Viper, logger logrus.FieldLogger) *EnvironmentRegistry {
+	config.SetDefault("environments.names", "")
+	config.SetDefault("environments.timeout", "5s")
+	config.SetDefault("environments.max_retries", 3)
+	config.SetDefault("environments.initial_backoff", "200ms")
+	config.SetDefault("environments.max_backoff", "5s")
+
+	registry := &EnvironmentRegistry{environments: make(map[string]*Environment)}
+	for _, name := range strings.Split(config.GetString("environments.names"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !environmentNamePattern.MatchString(name) {
+			logger.WithField("environment", name).Warn("Skipping environment, names can only have letters, digits and underscores")
+			continue
+		}
+
+		// per environment settings fall back to the shared ones
+		setting := func(key string) string {
+			if config.IsSet("environments." + name + "." + key) {
+				return "environments." + name + "." + key
+			}
+			return "environments." + key
+		}
+
+		environment := &Environment{
+			Name:           name,
+			BaseURL:        strings.TrimRight(config.GetString("environments."+name+".url"), "/"),
+			Token:          config.GetString("environments." + name + ".token"),
+			Timeout:        config.GetDuration(setting("timeout")),
+			MaxRetries:     config.GetInt(setting("max_retries")),
+			InitialBackoff: config.GetDuration(setting("initial_backoff")),
+			MaxBackoff:     config.GetDuration(setting("max_backoff")),
+		}
+		environment.ServiceToken = config.GetString("environments." + name + ".service_token")
+		if environment.ServiceToken == "" {
+			environment.ServiceToken = environment.Token
+		}
+		if environment.BaseURL == "" {
+			logger.WithField("environment", name).Warn("Skipping environment without a url")
+			continue
+		}
+		// every attempt would time out before it is sent
+		if environment.Timeout <= 0 {
+			logger.WithField("environment", name).Warn("Skipping environment, its timeout must be positive")
+			continue
+		}
+
+		registry.environments[name] = environment
+		logger.WithFields(logrus.Fields{
+			"environment": name,
+			"url":         environment.BaseURL,
+		}).Info("Registered environment")
+	}
+
+	return registry
+}
+
+func (r *EnvironmentRegistry) Get(name string) (*Environment, error) {
+	environment, ok := r.environments[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEnvironment, name)
+	}
+	return environment, nil
+}
+
+// EnvironmentResponseError is returned when an environment answers with a
+// status the caller doesn't handle, after retries when the status is retryable
+type EnvironmentResponseError struct {
+	Environment string
+	StatusCode  int
+	Body        string
+}
+
+func (e *EnvironmentResponseError) Error() string {
+	return fmt.Sprintf("environment %s responded %d: %s", e.Environment, e.StatusCode, e.Body)
+}
+
+// EnvironmentClient calls other environments, retrying network errors, 429s
+// and 5xx responses with exponential backoff
+type EnvironmentClient struct {
+	httpClient *http.Client
+	logger     logrus.FieldLogger
+}
+
+func NewEnvironmentClient(logger logrus.FieldLogger) *EnvironmentClient {
+	return &EnvironmentClient{httpClient: &http.Client{}, logger: logger}
+}
+
+// Do sends the request and returns the status and body of the final attempt.
+// Each attempt is bounded by the environment's timeout, and ctx bounds them all.
+func (c *EnvironmentClient) Do(ctx context.Context, environment *Environment, method, url string, body []byte) (int, []byte, error) {
+	logger := c.logger.WithFields(logrus.Fields{
+		"method":      "EnvironmentClient.Do",
+		"environment": environment.Name,
+		"url":         url,
+	})
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		statusCode, responseBody, err := c.attempt(ctx, environment, method, url, body)
+		if err == nil && !isRetryableStatus(statusCode) {
+			return statusCode, responseBody, nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = &EnvironmentResponseError{Environment: environment.Name, StatusCode: statusCode, Body: string(responseBody)}
+		}
+
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		if attempt >= environment.MaxRetries {
+			return 0, nil, fmt.Errorf("giving up after %d attempts: %w", attempt+1, lastErr)
+		}
+
+		backoff := retryBackoff(environment, attempt)
+		logger.WithFields(logrus.Fields{
+			"attempt": attempt + 1,
+			"backoff": backoff,
+			"error":   lastErr,
+		}).Warn("Request to environment failed, retrying")
+
+		select {
+		case <-ctx.Done():
+			return 0, nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+func (c *EnvironmentClient) attempt(ctx context.Context, environment *Environment, method, url string, body []byte) (int, []byte, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, environment.Timeout)
+	defer cancel()
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(attemptCtx, method, url, reqBody)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if environment.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+environment.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, responseBody, nil
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// retryBackoff doubles the initial backoff on every attempt, up to the max,
+// and adds up to 50% of jitter so retries from many workers spread out
+func retryBackoff(environment *Environment, attempt int) time.Duration {
+	backoff := environment.MaxBackoff
+	if attempt < 30 && environment.InitialBackoff<<attempt < backoff {
+		backoff = environment.InitialBackoff << attempt
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff + time.Duration(rand.Int63n(int64(backoff)/2+1))
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnvironment(url string) *Environment {
+	return &Environment{
+		Name:           "target",
+		BaseURL:        url,
+		Token:          "user-token",
+		ServiceToken:   testServiceToken,
+		Timeout:        time.Second,
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+}
+
+// respondInOrder answers with statuses one after the other, repeating the last one
+func respondInOrder(attempts *int32, statuses ...int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attempt := int(atomic.AddInt32(attempts, 1)) - 1
+		if attempt >= len(statuses) {
+			attempt = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[attempt])
+	}
+}
+
+func TestEnvironmentClientRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		statuses         []int
+		expectedStatus   int
+		expectedAttempts int32
+		expectedErr      bool
+	}{
+		{name: "success", statuses: []int{http.StatusOK}, expectedStatus: http.StatusOK, expectedAttempts: 1},
+		{name: "not found is not retried", statuses: []int{http.StatusNotFound}, expectedStatus: http.StatusNotFound, expectedAttempts: 1},
+		{name: "bad request is not retried", statuses: []int{http.StatusBadRequest}, expectedStatus: http.StatusBadRequest, expectedAttempts: 1},
+		{name: "server error is retried", statuses: []int{http.StatusServiceUnavailable, http.StatusOK}, expectedStatus: http.StatusOK, expectedAttempts: 2},
+		{name: "too many requests is retried", statuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusOK}, expectedStatus: http.StatusOK, expectedAttempts: 3},
+		{name: "gives up after max retries", statuses: []int{http.StatusInternalServerError}, expectedAttempts: 3, expectedErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(respondInOrder(&attempts, test.statuses...))
+			defer server.Close()
+
+			client := NewEnvironmentClient(testLogger())
+			statusCode, _, err := client.Do(context.Background(), testEnvironment(server.URL), http.MethodGet, server.URL, nil)
+			require.Equal(t, test.expectedAttempts, atomic.LoadInt32(&attempts))
+			if test.expectedErr {
+				var responseErr *EnvironmentResponseError
+				require.ErrorAs(t, err, &responseErr)
+				require.Equal(t, test.statuses[len(test.statuses)-1], responseErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectedStatus, statusCode)
+		})
+	}
+}
+
+func TestEnvironmentClientTimeouts(t *testing.T) {
+	release := make(chan struct{})
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewEnvironmentClient(testLogger())
+
+	t.Run("each attempt is bounded by the environment timeout", func(t *testing.T) {
+		atomic.StoreInt32(&attempts, 0)
+		environment := testEnvironment(server.URL)
+		environment.Timeout = 20 * time.Millisecond
+		environment.MaxRetries = 1
+
+		_, _, err := client.Do(context.Background(), environment, http.MethodGet, server.URL, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("the context bounds all attempts", func(t *testing.T) {
+		environment := testEnvironment(server.URL)
+		environment.Timeout = time.Minute
+		environment.MaxRetries = 5
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		_, _, err := client.Do(ctx, environment, http.MethodGet, server.URL, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(started), 5*time.Second)
+	})
+}
+
+func TestRetryBackoff(t *testing.T) {
+	environment := &Environment{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{attempt: 0, base: 100 * time.Millisecond},
+		{attempt: 1, base: 200 * time.Millisecond},
+		{attempt: 2, base: 400 * time.Millisecond},
+		{attempt: 3, base: 800 * time.Millisecond},
+		{attempt: 4, base: time.Second},
+		{attempt: 40, base: time.Second},
+	}
+	for _, test := range tests {
+		backoff := retryBackoff(environment, test.attempt)
+		require.GreaterOrEqual(t, backoff, test.base, "attempt %d", test.attempt)
+		require.LessOrEqual(t, backoff, test.base+test.base/2, "attempt %d", test.attempt)
+	}
+
+	require.Zero(t, retryBackoff(&Environment{}, 0))
+}
+
+func TestGetTargetWorld(t *testing.T) {
+	world := models.World{ID: uuid.New(), UserID: uuid.New(), Name: "Private", Version: 4, Visibility: models.WorldVisibilityPrivate}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer "+testServiceToken, r.Header.Get("Authorization"))
+		if r.URL.Path != "/internal/worlds/"+world.ID.String() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(world))
+	}))
+	defer server.Close()
+
+	config := viper.New()
+	config.Set("environments.names", "target")
+	config.Set("environments.target.url", server.URL)
+	config.Set("environments.target.token", "user-token")
+	config.Set("environments.target.service_token", testServiceToken)
+	logger := testLogger()
+	importer := NewWorldsImporterService(nil, nil, logger, NewEnvironmentRegistry(config, logger), NewEnvironmentClient(logger), nil)
+
+	targetWorld, err := importer.getTargetWorld(context.Background(), world.ID, "target")
+	require.NoError(t, err)
+	require.NotNil(t, targetWorld)
+	require.Equal(t, world.Version, targetWorld.Version)
+
+	// worlds missing from the target are not an error
+	targetWorld, err = importer.getTargetWorld(context.Background(), uuid.New(), "target")
+	require.NoError(t, err)
+	require.Nil(t, targetWorld)
+
+	_, err = importer.getTargetWorld(context.Background(), world.ID, "unknown")
+	require.ErrorIs(t, err, ErrUnknownEnvironment)
+}
+
+func TestEnvironmentRegistryRejectsInvalidTimeouts(t *testing.T) {
+	config := viper.New()
+	config.Set("environments.names", "staging,production,local")
+	config.Set("environments.staging.url", "http://staging")
+	config.Set("environments.production.url", "http://production")
+	config.Set("environments.production.timeout", "0s")
+	config.Set("environments.local.url", "http://local")
+	config.Set("environments.local.timeout", "-1s")
+
+	registry := NewEnvironmentRegistry(config, testLogger())
+	_, err := registry.Get("staging")
+	require.NoError(t, err)
+	_, err = registry.Get("production")
+	require.ErrorIs(t, err, ErrUnknownEnvironment)
+	_, err = registry.Get("local")
+	require.ErrorIs(t, err, ErrUnknownEnvironment)
+}
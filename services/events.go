@@ -17,8 +17,12 @@ type Event interface {
 	GetLogMetadata() map[string]interface{}
 }
 
+// WorldTransferRequestedEventType is consumed by the transfer worker
+const WorldTransferRequestedEventType = "world.transfer_requested"
+
 type WorldTransferRequestedEvent struct {
 	Type              string    `json:"type"`
+	JobID             uuid.UUID `json:"job_id"`
 	WorldID           uuid.UUID `json:"world_id"`
 	UserID            uuid.UUID `json:"user_id"`
 	WorldVersion      int       `json:"world_version"`
@@ -32,6 +36,7 @@ func (e *WorldTransferRequestedEvent) GetType() string {
 
 func (e *WorldTransferRequestedEvent) GetLogMetadata() map[string]interface{} {
 	return map[string]interface{}{
+		"job_id":             e.JobID,
 		"world_id":           e.WorldID,
 		"user_id":            e.UserID,
 		"world_version":      e.WorldVersion,
@@ -39,18 +44,47 @@ func (e *WorldTransferRequestedEvent) GetLogMetadata() map[string]interface{} {
 	}
 }
 
+type WorldOwnershipTransferredEvent struct {
+	Type         string    `json:"type"`
+	WorldID      uuid.UUID `json:"world_id"`
+	FromUserID   uuid.UUID `json:"from_user_id"`
+	ToUserID     uuid.UUID `json:"to_user_id"`
+	WorldVersion int       `json:"world_version"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+func (e *WorldOwnershipTransferredEvent) GetType() string {
+	return e.Type
+}
+
+func (e *WorldOwnershipTransferredEvent) GetLogMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"world_id":      e.WorldID,
+		"from_user_id":  e.FromUserID,
+		"to_user_id":    e.ToUserID,
+		"world_version": e.WorldVersion,
+	}
+}
+
 type EventPublisher interface {
 	PublishWorldCreated(ctx context.Context, world *models.World)
-	PublishWorldUpdated(ctx context.Context, world *models.World)
+	PublishWorldUpdated(ctx context.Context, world *models.World, changedFields []string)
 	PublishWorldTransferRequested(ctx context.Context, worldTransferRequestedEvent *WorldTransferRequestedEvent)
+	PublishWorldLeft(ctx context.Context, userID, worldID uuid.UUID)
+	PublishWorldDeleted(ctx context.Context, world *models.World)
+	PublishWorldRestored(ctx context.Context, world *models.World)
+	PublishWorldForked(ctx context.Context, world *models.World)
+	PublishUserKicked(ctx context.Context, userID, worldID, kickedBy uuid.UUID)
+	PublishWorldOwnershipTransferred(ctx context.Context, world *models.World, fromUserID uuid.UUID)
 }
 
 type WorldEvent struct {
-	Type      string      `json:"type"`
-	WorldID   uuid.UUID   `json:"world_id"`
-	UserID    uuid.UUID   `json:"user_id"`
-	Data      interface{} `json:"data"`
-	Timestamp time.Time   `json:"timestamp"`
+	Type          string      `json:"type"`
+	WorldID       uuid.UUID   `json:"world_id"`
+	UserID        uuid.UUID   `json:"user_id"`
+	Data          interface{} `json:"data"`
+	ChangedFields []string    `json:"changed_fields,omitempty"`
+	Timestamp     time.Time   `json:"timestamp"`
 }
 
 func (e *WorldEvent) GetType() string {
@@ -89,12 +123,61 @@ func (p *RedisAsyncEventPublisher) PublishWorldCreated(ctx context.Context, worl
 }
 
 func (p *RedisAsyncEventPublisher) PublishWorldTransferRequested(ctx context.Context, worldTransferRequestedEvent *WorldTransferRequestedEvent) {
+	worldTransferRequestedEvent.Type = WorldTransferRequestedEventType
+	worldTransferRequestedEvent.Timestamp = time.Now()
 	p.publishEvent(ctx, "worlds", worldTransferRequestedEvent)
 }
 
-func (p *RedisAsyncEventPublisher) PublishWorldUpdated(ctx context.Context, world *models.World) {
+func (p *RedisAsyncEventPublisher) PublishWorldUpdated(ctx context.Context, world *models.World, changedFields []string) {
+	event := WorldEvent{
+		Type:          "world.updated",
+		WorldID:       world.ID,
+		UserID:        world.UserID,
+		Data:          world,
+		ChangedFields: changedFields,
+		Timestamp:     time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
+func (p *RedisAsyncEventPublisher) PublishWorldLeft(ctx context.Context, userID, worldID uuid.UUID) {
+	event := WorldEvent{
+		Type:      "world.left",
+		WorldID:   worldID,
+		UserID:    userID,
+		Timestamp: time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
+func (p *RedisAsyncEventPublisher) PublishWorldDeleted(ctx context.Context, world *models.World) {
+	event := WorldEvent{
+		Type:      "world.deleted",
+		WorldID:   world.ID,
+		UserID:    world.UserID,
+		Timestamp: time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
+func (p *RedisAsyncEventPublisher) PublishWorldRestored(ctx context.Context, world *models.World) {
+	event := WorldEvent{
+		Type:      "world.restored",
+		WorldID:   world.ID,
+		UserID:    world.UserID,
+		Data:      world,
+		Timestamp: time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
+func (p *RedisAsyncEventPublisher) PublishWorldForked(ctx context.Context, world *models.World) {
 	event := WorldEvent{
-		Type:      "world.updated",
+		Type:      "world.forked",
 		WorldID:   world.ID,
 		UserID:    world.UserID,
 		Data:      world,
@@ -104,6 +187,32 @@ func (p *RedisAsyncEventPublisher) PublishWorldUpdated(ctx context.Context, worl
 	p.publishEvent(ctx, "worlds", &event)
 }
 
+// PublishUserKicked is sent after the world.left of a user removed by a moderator
+func (p *RedisAsyncEventPublisher) PublishUserKicked(ctx context.Context, userID, worldID, kickedBy uuid.UUID) {
+	event := WorldEvent{
+		Type:      "world.kicked",
+		WorldID:   worldID,
+		UserID:    userID,
+		Data:      map[string]uuid.UUID{"kicked_by": kickedBy},
+		Timestamp: time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
+func (p *RedisAsyncEventPublisher) PublishWorldOwnershipTransferred(ctx context.Context, world *models.World, fromUserID uuid.UUID) {
+	event := WorldOwnershipTransferredEvent{
+		Type:         "world.ownership_transferred",
+		WorldID:      world.ID,
+		FromUserID:   fromUserID,
+		ToUserID:     world.UserID,
+		WorldVersion: world.Version,
+		Timestamp:    time.Now(),
+	}
+
+	p.publishEvent(ctx, "worlds", &event)
+}
+
 func (p *RedisAsyncEventPublisher) publishEvent(ctx context.Context, channel string, event Event) {
 	utils.SafeGo(ctx, func() {
 		logger := p.logger.WithFields(logrus.Fields{
@@ -127,3 +236,48 @@ func (p *RedisAsyncEventPublisher) publishEvent(ctx context.Context, channel str
 	})
 
 }
+
+type RedisEventSubscriber struct {
+	client *redis.Client
+	logger logrus.FieldLogger
+}
+
+func NewRedisEventSubscriber(client *redis.Client, logger logrus.FieldLogger) *RedisEventSubscriber {
+	return &RedisEventSubscriber{
+		client: client,
+		logger: logger,
+	}
+}
+
+// Subscribe calls handle with the payload of every message published on
+// channel until ctx is done. Messages published while nobody is subscribed
+// are lost, that's how redis pub/sub works.
+func (s *RedisEventSubscriber) Subscribe(ctx context.Context, channel string, handle func(payload []byte)) error {
+	logger := s.logger.WithFields(logrus.Fields{
+		"method":  "Subscribe",
+		"channel": channel,
+	})
+
+	pubsub := s.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	// wait for the subscription to be confirmed so nothing published after
+	// Subscribe returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+	logger.Info("Subscribed")
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			handle([]byte(message.Payload))
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidInvite      = errors.New("invalid invite")
+	ErrInvalidInviteToken = errors.New("invalid invite token")
+	ErrInviteUnavailable  = errors.New("invite is no longer valid")
+)
+
+type InvitesService struct {
+	dal           *dal.DAL
+	logger        logrus.FieldLogger
+	config        *viper.Viper
+	policy        *Policy
+	worldsService *WorldsService
+	signingSecret []byte
+}
+
+func NewInvitesService(
+	config *viper.Viper,
+	dal *dal.DAL,
+	logger logrus.FieldLogger,
+	policy *Policy,
+	worldsService *WorldsService,
+) *InvitesService {
+	config.SetDefault("invites.default_ttl", "168h")
+
+	signingSecret := []byte(config.GetString("invites.signing_secret"))
+	if len(signingSecret) == 0 {
+		logger.Warn("invites.signing_secret is not set, invite links stop working when the server restarts")
+		signingSecret = make([]byte, 32)
+		if _, err := rand.Read(signingSecret); err != nil {
+			logger.WithError(err).Fatal("Failed to generate invite signing secret")
+		}
+	}
+
+	return &InvitesService{
+		dal:           dal,
+		logger:        logger,
+		config:        config,
+		policy:        policy,
+		worldsService: worldsService,
+		signingSecret: signingSecret,
+	}
+}
+
+// CreateInviteParams describes a new invite. Invites for a specific user can
+// only be used once, link invites are limited by MaxUses (0 is unlimited).
+type CreateInviteParams struct {
+	InviteeID *uuid.UUID
+	Role      models.WorldMemberRole
+	MaxUses   int
+	ExpiresAt *time.Time
+}
+
+func (s *InvitesService) CreateInvite(actor Actor, worldID uuid.UUID, params CreateInviteParams) (*models.WorldInvite, error) {
+	if params.Role == "" {
+		params.Role = models.WorldMemberRolePlayer
+	}
+	if !params.Role.IsValid() || params.Role == models.WorldMemberRoleOwner {
+		return nil, ErrInvalidRole
+	}
+
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanManageInvites(actor, world); err != nil {
+		return nil, err
+	}
+
+	invite := &models.WorldInvite{
+		ID:        uuid.New(),
+		WorldID:   worldID,
+		CreatedBy: actor.UserID,
+		Role:      params.Role,
+		MaxUses:   params.MaxUses,
+		ExpiresAt: time.Now().Add(s.config.GetDuration("invites.default_ttl")),
+	}
+
+	if params.ExpiresAt != nil {
+		if !params.ExpiresAt.After(time.Now()) {
+			return nil, ErrInvalidInvite
+		}
+		invite.ExpiresAt = *params.ExpiresAt
+	}
+
+	if params.InviteeID != nil {
+		if _, err := s.dal.UserDAL.GetUserByID(*params.InviteeID); err != nil {
+			return nil, err
+		}
+		invite.InviteeID = params.InviteeID
+		invite.MaxUses = 1
+	}
+
+	if err := s.dal.WorldInvitesDAL.CreateWorldInvite(invite); err != nil {
+		return nil, err
+	}
+	invite.Token = s.signInviteToken(invite.ID)
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":   worldID,
+		"invite_id":  invite.ID,
+		"invitee_id": invite.InviteeID,
+		"role":       invite.Role,
+	}).Info("Invite created")
+
+	return invite, nil
+}
+
+// GetWorldInvites lists the invites of a world that can still be accepted
+func (s *InvitesService) GetWorldInvites(actor Actor, worldID uuid.UUID) ([]models.WorldInvite, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanManageInvites(actor, world); err != nil {
+		return nil, err
+	}
+
+	invites, err := s.dal.WorldInvitesDAL.GetOutstandingWorldInvites(worldID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withTokens(invites), nil
+}
+
+// GetUserInvites lists the invites addressed to the actor that can still be accepted
+func (s *InvitesService) GetUserInvites(actor Actor) ([]models.WorldInvite, error) {
+	invites, err := s.dal.WorldInvitesDAL.GetOutstandingInvitesForUser(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withTokens(invites), nil
+}
+
+func (s *InvitesService) RevokeInvite(actor Actor, worldID, inviteID uuid.UUID) error {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return ErrWorldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.CanManageInvites(actor, world); err != nil {
+		return err
+	}
+
+	return s.dal.WorldInvitesDAL.RevokeWorldInvite(worldID, inviteID)
+}
+
+// InviteAcceptance is the outcome of accepting an invite. JoinErr is why
+// joining the world afterwards failed, which doesn't undo the accept.
+type InviteAcceptance struct {
+	Member  *models.WorldMember
+	Joined  bool
+	JoinErr error
+}
+
+// AcceptInvite gives the actor the invite's role in its world, unless they
+// already have a higher one, and joins the world when join is true. Each
+// user uses up the invite once, accepting it again succeeds without using it
+// further so a failed join can be retried.
+func (s *InvitesService) AcceptInvite(ctx context.Context, actor Actor, token string, join bool) (*InviteAcceptance, error) {
+	inviteID, err := s.parseInviteToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := s.dal.WorldInvitesDAL.GetWorldInvite(inviteID)
+	if err == pg.ErrNoRows {
+		return nil, ErrInvalidInviteToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invite.InviteeID != nil && *invite.InviteeID != actor.UserID {
+		return nil, ErrForbidden
+	}
+
+	world, err := s.dal.WorldsDAL.GetWorldByID(invite.WorldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CheckNotBanned(actor, world); err != nil {
+		return nil, err
+	}
+
+	role, err := s.policy.WorldRole(actor, world)
+	if err != nil {
+		return nil, err
+	}
+	member := &models.WorldMember{WorldID: world.ID, UserID: actor.UserID, Role: role}
+	var upsert *models.WorldMember
+	if !role.AtLeast(invite.Role) {
+		member.Role = invite.Role
+		upsert = member
+	}
+
+	err = s.dal.WorldInvitesDAL.AcceptWorldInvite(invite.ID, actor.UserID, upsert)
+	if err == dal.ErrInviteUnavailable {
+		return nil, ErrInviteUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":  world.ID,
+		"invite_id": invite.ID,
+		"user_id":   actor.UserID,
+		"role":      member.Role,
+	}).Info("Invite accepted")
+
+	acceptance := &InviteAcceptance{Member: member}
+	if join {
+		acceptance.JoinErr = s.worldsService.JoinWorld(ctx, actor, world.ID)
+		acceptance.Joined = acceptance.JoinErr == nil
+		if acceptance.JoinErr != nil {
+			s.logger.WithFields(logrus.Fields{
+				"world_id": world.ID,
+				"user_id":  actor.UserID,
+			}).WithError(acceptance.JoinErr).Warn("Invite accepted but joining the world failed")
+		}
+	}
+	return acceptance, nil
+}
+
+func (s *InvitesService) withTokens(invites []models.WorldInvite) []models.WorldInvite {
+	for i := range invites {
+		invites[i].Token = s.signInviteToken(invites[i].ID)
+	}
+	return invites
+}
+
+// signInviteToken builds the shareable token of an invite: its ID and an
+// HMAC of the ID, so tokens can't be guessed from invite IDs
+func (s *InvitesService) signInviteToken(inviteID uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString(inviteID[:]) + "." +
+		base64.RawURLEncoding.EncodeToString(s.inviteSignature(inviteID))
+}
+
+func (s *InvitesService) parseInviteToken(token string) (uuid.UUID, error) {
+	encodedID, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidInviteToken
+	}
+
+	idBytes, err := base64.RawURLEncoding.DecodeString(encodedID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidInviteToken
+	}
+	inviteID, err := uuid.FromBytes(idBytes)
+	if err != nil {
+		return uuid.Nil, ErrInvalidInviteToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, s.inviteSignature(inviteID)) {
+		return uuid.Nil, ErrInvalidInviteToken
+	}
+	return inviteID, nil
+}
+
+func (s *InvitesService) inviteSignature(inviteID uuid.UUID) []byte {
+	mac := hmac.New(sha256.New, s.signingSecret)
+	mac.Write(inviteID[:])
+	return mac.Sum(nil)
+}
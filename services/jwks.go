@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKeyID = errors.New("unknown key id")
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// JWKSKeySet holds the public keys loaded from a JWKS file, or from every
+// *.json file in a directory. Keys are looked up by kid, and the set is
+// reloaded from disk when an unknown kid shows up so keys can be rotated
+// without restarting the server.
+type JWKSKeySet struct {
+	path              string
+	minReloadInterval time.Duration
+	mu                sync.RWMutex
+	keys              map[string]interface{}
+	lastReloadAttempt time.Time
+}
+
+func NewJWKSKeySet(path string, minReloadInterval time.Duration) (*JWKSKeySet, error) {
+	keySet := &JWKSKeySet{
+		path:              path,
+		minReloadInterval: minReloadInterval,
+		keys:              make(map[string]interface{}),
+	}
+	if err := keySet.reload(); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+func (k *JWKSKeySet) Key(kid string) (interface{}, error) {
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	canReload := time.Since(k.lastReloadAttempt) >= k.minReloadInterval
+	k.mu.Unlock()
+	if canReload {
+		if err := k.reload(); err != nil {
+			return nil, err
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKeyID, kid)
+}
+
+func (k *JWKSKeySet) lookup(kid string) (interface{}, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	// tokens without a kid are only accepted when there is no ambiguity
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *JWKSKeySet) reload() error {
+	k.mu.Lock()
+	k.lastReloadAttempt = time.Now()
+	k.mu.Unlock()
+
+	files, err := jwksFiles(k.path)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var keySet jsonWebKeySet
+		if err := json.Unmarshal(content, &keySet); err != nil {
+			return fmt.Errorf("invalid JWKS file %s: %w", file, err)
+		}
+
+		for _, jwk := range keySet.Keys {
+			if jwk.Use != "" && jwk.Use != "sig" {
+				continue
+			}
+			key, err := jwk.publicKey()
+			if err != nil {
+				return fmt.Errorf("invalid key %q in %s: %w", jwk.Kid, file, err)
+			}
+			keys[jwk.Kid] = key
+		}
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+func jwksFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	return filepath.Glob(filepath.Join(path, "*.json"))
+}
+
+func (j *jsonWebKey) publicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		// ES256 is the only EC signing method accepted, so only its curve is loaded
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
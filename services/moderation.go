@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrCannotModerateOwner = errors.New("the world's owner cannot be kicked or banned")
+	ErrInvalidBan          = errors.New("invalid ban")
+)
+
+type ModerationService struct {
+	dal            *dal.DAL
+	logger         logrus.FieldLogger
+	eventPublisher EventPublisher
+	policy         *Policy
+}
+
+func NewModerationService(dal *dal.DAL, logger logrus.FieldLogger, eventPublisher EventPublisher, policy *Policy) *ModerationService {
+	return &ModerationService{dal: dal, logger: logger, eventPublisher: eventPublisher, policy: policy}
+}
+
+// moderatedWorld loads the world and checks the actor can moderate userID in it
+func (s *ModerationService) moderatedWorld(actor Actor, worldID, userID uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanModerateWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	if world.UserID == userID {
+		return nil, ErrCannotModerateOwner
+	}
+	return world, nil
+}
+
+// KickUser removes the user from the world. They can join again right away
+// unless they are also banned.
+func (s *ModerationService) KickUser(ctx context.Context, actor Actor, worldID, userID uuid.UUID) error {
+	if _, err := s.moderatedWorld(actor, worldID, userID); err != nil {
+		return err
+	}
+
+	kicked, err := s.kick(ctx, actor, worldID, userID)
+	if err != nil {
+		return err
+	}
+	if !kicked {
+		return ErrUserNotInWorld
+	}
+	return nil
+}
+
+func (s *ModerationService) kick(ctx context.Context, actor Actor, worldID, userID uuid.UUID) (bool, error) {
+	leftWorldID, err := s.dal.WorldsDAL.LeaveWorld(ctx, userID, worldID)
+	if err != nil {
+		return false, fmt.Errorf("failed to kick user: %w", err)
+	}
+	if leftWorldID == uuid.Nil {
+		return false, nil
+	}
+
+	s.eventPublisher.PublishWorldLeft(context.Background(), userID, worldID)
+	s.eventPublisher.PublishUserKicked(context.Background(), userID, worldID, actor.UserID)
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":  worldID,
+		"user_id":   userID,
+		"kicked_by": actor.UserID,
+	}).Info("User kicked from world")
+
+	return true, nil
+}
+
+// BanUser keeps the user from joining the world until expiresAt, or for good
+// when it is nil, and kicks them if they are in it. Banning an already banned
+// user replaces the ban.
+func (s *ModerationService) BanUser(ctx context.Context, actor Actor, worldID, userID uuid.UUID, reason string, expiresAt *time.Time) (*models.WorldBan, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrInvalidBan
+	}
+
+	if _, err := s.moderatedWorld(actor, worldID, userID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.dal.UserDAL.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	ban := &models.WorldBan{
+		WorldID:   worldID,
+		UserID:    userID,
+		BannedBy:  actor.UserID,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.dal.WorldBansDAL.UpsertWorldBan(ban); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":   worldID,
+		"user_id":    userID,
+		"banned_by":  actor.UserID,
+		"expires_at": expiresAt,
+	}).Info("User banned from world")
+
+	if _, err := s.kick(ctx, actor, worldID, userID); err != nil {
+		return nil, err
+	}
+
+	return ban, nil
+}
+
+func (s *ModerationService) UnbanUser(actor Actor, worldID, userID uuid.UUID) error {
+	if _, err := s.moderatedWorld(actor, worldID, userID); err != nil {
+		return err
+	}
+
+	return s.dal.WorldBansDAL.DeleteWorldBan(worldID, userID)
+}
+
+// GetBans lists the world's bans that haven't expired
+func (s *ModerationService) GetBans(actor Actor, worldID uuid.UUID) ([]models.WorldBan, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanModerateWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	return s.dal.WorldBansDAL.GetActiveWorldBans(worldID)
+}
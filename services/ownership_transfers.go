@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInvalidOwnershipTransfer = errors.New("a world can't be transferred to its current owner")
+	ErrNoPendingTransfer        = errors.New("no pending ownership transfer")
+)
+
+type OwnershipTransfersService struct {
+	dal            *dal.DAL
+	logger         logrus.FieldLogger
+	config         *viper.Viper
+	eventPublisher EventPublisher
+	policy         *Policy
+	worldsService  *WorldsService
+}
+
+func NewOwnershipTransfersService(
+	config *viper.Viper,
+	dal *dal.DAL,
+	logger logrus.FieldLogger,
+	eventPublisher EventPublisher,
+	policy *Policy,
+	worldsService *WorldsService,
+) *OwnershipTransfersService {
+	config.SetDefault("ownership_transfers.ttl", "72h")
+
+	return &OwnershipTransfersService{
+		dal:            dal,
+		logger:         logger,
+		config:         config,
+		eventPublisher: eventPublisher,
+		policy:         policy,
+		worldsService:  worldsService,
+	}
+}
+
+func (s *OwnershipTransfersService) getWorld(worldID uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	return world, err
+}
+
+// OfferOwnership asks toUserID to take over the world. It replaces the
+// world's pending offer if there is one.
+func (s *OwnershipTransfersService) OfferOwnership(actor Actor, worldID, toUserID uuid.UUID) (*models.WorldOwnershipTransfer, error) {
+	world, err := s.getWorld(worldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanTransferOwnership(actor, world); err != nil {
+		return nil, err
+	}
+
+	if world.UserID == toUserID {
+		return nil, ErrInvalidOwnershipTransfer
+	}
+
+	if _, err := s.dal.UserDAL.GetUserByID(toUserID); err != nil {
+		return nil, err
+	}
+
+	transfer := &models.WorldOwnershipTransfer{
+		ID:         uuid.New(),
+		WorldID:    worldID,
+		FromUserID: world.UserID,
+		ToUserID:   toUserID,
+		ExpiresAt:  time.Now().Add(s.config.GetDuration("ownership_transfers.ttl")),
+	}
+	if err := s.dal.WorldOwnershipTransfersDAL.CreateOwnershipTransfer(transfer); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":     worldID,
+		"transfer_id":  transfer.ID,
+		"from_user_id": transfer.FromUserID,
+		"to_user_id":   toUserID,
+	}).Info("Ownership transfer offered")
+
+	return transfer, nil
+}
+
+// GetPendingTransfer returns the world's pending offer. Only the owner and
+// the recipient can see it.
+func (s *OwnershipTransfersService) GetPendingTransfer(actor Actor, worldID uuid.UUID) (*models.WorldOwnershipTransfer, error) {
+	world, err := s.getWorld(worldID)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.pendingTransfer(worldID)
+	if err != nil && err != ErrNoPendingTransfer {
+		return nil, err
+	}
+
+	if transfer == nil || transfer.ToUserID != actor.UserID {
+		if err := s.policy.CanTransferOwnership(actor, world); err != nil {
+			return nil, err
+		}
+	}
+	if transfer == nil {
+		return nil, ErrNoPendingTransfer
+	}
+	return transfer, nil
+}
+
+// GetUserPendingTransfers lists the offers the actor can still accept
+func (s *OwnershipTransfersService) GetUserPendingTransfers(actor Actor) ([]models.WorldOwnershipTransfer, error) {
+	return s.dal.WorldOwnershipTransfersDAL.GetPendingOwnershipTransfersForUser(actor.UserID)
+}
+
+func (s *OwnershipTransfersService) CancelTransfer(actor Actor, worldID uuid.UUID) error {
+	world, err := s.getWorld(worldID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.CanTransferOwnership(actor, world); err != nil {
+		return err
+	}
+
+	return s.resolveTransfer(worldID, models.OwnershipTransferCancelled, nil)
+}
+
+func (s *OwnershipTransfersService) DeclineTransfer(actor Actor, worldID uuid.UUID) error {
+	return s.resolveTransfer(worldID, models.OwnershipTransferDeclined, &actor.UserID)
+}
+
+// resolveTransfer closes the world's pending offer, which must be addressed
+// to recipientID when it is set
+func (s *OwnershipTransfersService) resolveTransfer(worldID uuid.UUID, status models.OwnershipTransferStatus, recipientID *uuid.UUID) error {
+	transfer, err := s.pendingTransfer(worldID)
+	if err != nil {
+		return err
+	}
+	if recipientID != nil && transfer.ToUserID != *recipientID {
+		return ErrForbidden
+	}
+
+	err = s.dal.WorldOwnershipTransfersDAL.ResolveOwnershipTransfer(transfer.ID, status)
+	if err == dal.ErrOwnershipTransferUnavailable {
+		return ErrNoPendingTransfer
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":    worldID,
+		"transfer_id": transfer.ID,
+		"status":      status,
+	}).Info("Ownership transfer resolved")
+
+	return nil
+}
+
+// AcceptTransfer makes the actor the owner of the world. The change is a new
+// version of the world, so it shows up in its revision history. The world
+// counts against the actor's quota like one they created.
+func (s *OwnershipTransfersService) AcceptTransfer(actor Actor, worldID uuid.UUID) (*models.World, error) {
+	transfer, err := s.pendingTransfer(worldID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.ToUserID != actor.UserID {
+		return nil, ErrForbidden
+	}
+
+	limit := s.worldsService.maxWorlds(actor.Role)
+	world, accepted, err := s.dal.WorldOwnershipTransfersDAL.AcceptOwnershipTransfer(transfer, limit)
+	if err == dal.ErrOwnershipTransferUnavailable {
+		return nil, ErrNoPendingTransfer
+	}
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, &WorldQuotaExceededError{Limit: limit}
+	}
+
+	s.eventPublisher.PublishWorldOwnershipTransferred(context.Background(), world, transfer.FromUserID)
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":     worldID,
+		"transfer_id":  transfer.ID,
+		"from_user_id": transfer.FromUserID,
+		"to_user_id":   world.UserID,
+	}).Info("World ownership transferred")
+
+	return world, nil
+}
+
+func (s *OwnershipTransfersService) pendingTransfer(worldID uuid.UUID) (*models.WorldOwnershipTransfer, error) {
+	transfer, err := s.dal.WorldOwnershipTransfersDAL.GetPendingOwnershipTransfer(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrNoPendingTransfer
+	}
+	return transfer, err
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+var (
+	ErrForbidden  = errors.New("forbidden")
+	ErrUserBanned = errors.New("user is banned from this world")
+)
+
+// Actor is the authenticated user performing a request
+type Actor struct {
+	UserID uuid.UUID
+	Role   models.Role
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role.AtLeast(models.RoleAdmin)
+}
+
+// Policy centralizes authorization decisions. Services ask the policy
+// instead of comparing owners themselves.
+type Policy struct {
+	dal *dal.DAL
+}
+
+func NewPolicy(dal *dal.DAL) *Policy {
+	return &Policy{dal: dal}
+}
+
+// WorldRole returns the actor's role in a world's ACL, or an empty role if
+// the actor is not a member. The world's owner is always an owner even
+// without a world_members row.
+func (p *Policy) WorldRole(actor Actor, world *models.World) (models.WorldMemberRole, error) {
+	if world.UserID == actor.UserID {
+		return models.WorldMemberRoleOwner, nil
+	}
+
+	member, err := p.dal.WorldMembersDAL.GetWorldMember(world.ID, actor.UserID)
+	if err == pg.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+func (p *Policy) requireWorldRole(actor Actor, world *models.World, role models.WorldMemberRole) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+
+	worldRole, err := p.WorldRole(actor, world)
+	if err != nil {
+		return err
+	}
+	if !worldRole.AtLeast(role) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (p *Policy) CanUpdateWorld(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleEditor)
+}
+
+// CanChangeVisibility leaves who can see a world to its owner, editors only
+// change its content
+func (p *Policy) CanChangeVisibility(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanDeleteWorld(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanRestoreWorld(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+// CanViewWorld hides private worlds from everyone but their members.
+// Public and unlisted worlds can be opened by anyone who knows their ID.
+func (p *Policy) CanViewWorld(actor Actor, world *models.World) error {
+	if world.Visibility != models.WorldVisibilityPrivate {
+		return nil
+	}
+	return p.requireWorldRole(actor, world, models.WorldMemberRolePlayer)
+}
+
+// CanJoinWorld only lets members into private worlds
+func (p *Policy) CanJoinWorld(actor Actor, world *models.World) error {
+	return p.CanViewWorld(actor, world)
+}
+
+// CheckNotBanned returns ErrUserBanned while the actor has an active ban
+// from the world
+func (p *Policy) CheckNotBanned(actor Actor, world *models.World) error {
+	_, err := p.dal.WorldBansDAL.GetActiveWorldBan(world.ID, actor.UserID)
+	if err == pg.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return ErrUserBanned
+}
+
+// CanForkWorld allows forking any world the actor can see
+func (p *Policy) CanForkWorld(actor Actor, world *models.World) error {
+	return p.CanViewWorld(actor, world)
+}
+
+// WorldsViewer describes which worlds a listing may return to the actor,
+// nil for anonymous requests
+func (p *Policy) WorldsViewer(actor *Actor) models.WorldsViewer {
+	if actor == nil {
+		return models.WorldsViewer{}
+	}
+	return models.WorldsViewer{UserID: &actor.UserID, SeesAll: actor.IsAdmin()}
+}
+
+func (p *Policy) CanViewWorldHistory(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleViewer)
+}
+
+func (p *Policy) CanViewCollaborators(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleViewer)
+}
+
+func (p *Policy) CanViewWorldMembers(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleViewer)
+}
+
+func (p *Policy) CanManageCollaborators(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanManageInvites(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanModerateWorld(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanTransferOwnership(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+// CanTransferWorld lets owners copy their worlds to other environments
+func (p *Policy) CanTransferWorld(actor Actor, world *models.World) error {
+	return p.requireWorldRole(actor, world, models.WorldMemberRoleOwner)
+}
+
+func (p *Policy) CanViewTransferJob(actor Actor, job *models.WorldsTransferJob) error {
+	if actor.IsAdmin() || job.UserID == actor.UserID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func (p *Policy) CanManageUsers(actor Actor) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPresenceExpired       = errors.New("user is not in any world, join again")
+	ErrInvalidPresenceConfig = errors.New("invalid presence config")
+)
+
+// GetWorldMembers pages through the users currently in a world. Users
+// without a record in Postgres are returned with only their ID.
+func (s *WorldsService) GetWorldMembers(ctx context.Context, actor Actor, worldID uuid.UUID, cursor uint64, limit int) (*models.WorldMembersPage, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanViewWorldMembers(actor, world); err != nil {
+		return nil, err
+	}
+
+	userIDs, nextCursor, err := s.dal.WorldsDAL.ScanWorldUsers(ctx, worldID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := s.dal.UserDAL.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[uuid.UUID]models.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	page := &models.WorldMembersPage{Members: make([]models.User, 0, len(userIDs))}
+	for _, userID := range userIDs {
+		user, ok := usersByID[userID]
+		if !ok {
+			user = models.User{ID: userID}
+		}
+		page.Members = append(page.Members, user)
+	}
+	if nextCursor != 0 {
+		next := strconv.FormatUint(nextCursor, 10)
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
+
+// presenceTTL is how long a user stays in a world without sending heartbeats
+func (s *WorldsService) presenceTTL() time.Duration {
+	return s.config.GetDuration("presence.ttl")
+}
+
+// Heartbeat keeps the user in their current world for another presence TTL
+func (s *WorldsService) Heartbeat(ctx context.Context, userID uuid.UUID) (uuid.UUID, time.Duration, error) {
+	worldID, err := s.dal.WorldsDAL.Heartbeat(ctx, userID, s.presenceTTL())
+	if err != nil {
+		return uuid.Nil, 0, err
+	}
+	if worldID == uuid.Nil {
+		return uuid.Nil, 0, ErrPresenceExpired
+	}
+	return worldID, s.presenceTTL(), nil
+}
+
+// presenceSweepConfig reads the sweeper settings. A zero interval would
+// make the ticker panic and a zero batch size would sweep forever.
+func (s *WorldsService) presenceSweepConfig() (time.Duration, int, error) {
+	interval := s.config.GetDuration("presence.sweep_interval")
+	if interval <= 0 {
+		return 0, 0, fmt.Errorf("%w: presence.sweep_interval must be positive, got %s", ErrInvalidPresenceConfig, interval)
+	}
+	batchSize := s.config.GetInt("presence.sweep_batch_size")
+	if batchSize <= 0 {
+		return 0, 0, fmt.Errorf("%w: presence.sweep_batch_size must be positive, got %d", ErrInvalidPresenceConfig, batchSize)
+	}
+	return interval, batchSize, nil
+}
+
+// SweepExpiredPresence removes users that stopped sending heartbeats from
+// their worlds and publishes a world.left event for each of them.
+func (s *WorldsService) SweepExpiredPresence(ctx context.Context) (int, error) {
+	_, batchSize, err := s.presenceSweepConfig()
+	if err != nil {
+		return 0, err
+	}
+	total := 0
+	for {
+		expired, err := s.dal.WorldsDAL.SweepExpiredPresence(ctx, s.presenceTTL(), batchSize)
+		if err != nil {
+			return total, err
+		}
+
+		for _, presence := range expired {
+			s.eventPublisher.PublishWorldLeft(ctx, presence.UserID, presence.WorldID)
+		}
+
+		total += len(expired)
+		if len(expired) < batchSize {
+			return total, nil
+		}
+	}
+}
+
+// StartPresenceSweeper runs SweepExpiredPresence every presence.sweep_interval
+// until ctx is done. It fails when the sweeper settings are invalid.
+func (s *WorldsService) StartPresenceSweeper(ctx context.Context) error {
+	logger := s.logger.WithField("method", "StartPresenceSweeper")
+	interval, _, err := s.presenceSweepConfig()
+	if err != nil {
+		return err
+	}
+
+	utils.SafeGo(ctx, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.WithField("interval", interval).Info("Presence sweeper started")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				removed, err := s.SweepExpiredPresence(ctx)
+				if err != nil {
+					logger.WithError(err).Error("Failed to sweep expired presence")
+					continue
+				}
+				if removed > 0 {
+					logger.WithFields(logrus.Fields{"removed": removed}).Info("Removed expired users from worlds")
+				}
+			}
+		}
+	})
+	return nil
+}
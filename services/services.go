@@ -7,9 +7,14 @@ import (
 )
 
 type Services struct {
-	WorldsService         *WorldsService
-	UserService           *UserService
-	WorldsImporterService *WorldsImporterService
+	WorldsService             *WorldsService
+	UserService               *UserService
+	WorldsImporterService     *WorldsImporterService
+	AuthService               *AuthService
+	CollaboratorsService      *CollaboratorsService
+	InvitesService            *InvitesService
+	ModerationService         *ModerationService
+	OwnershipTransfersService *OwnershipTransfersService
 }
 
 func NewServices(
@@ -18,13 +23,28 @@ func NewServices(
 	logger logrus.FieldLogger,
 	eventPublisher EventPublisher,
 ) *Services {
-	worldsService := NewWorldsService(config, dal, logger, eventPublisher)
-	userService := NewUserService(dal)
-	worldsImporterService := NewWorldsImporterService(eventPublisher, dal)
+	policy := NewPolicy(dal)
+	worldsService := NewWorldsService(config, dal, logger, eventPublisher, policy)
+	userService := NewUserService(dal, policy)
+	collaboratorsService := NewCollaboratorsService(dal, logger, policy)
+	invitesService := NewInvitesService(config, dal, logger, policy, worldsService)
+	moderationService := NewModerationService(dal, logger, eventPublisher, policy)
+	ownershipTransfersService := NewOwnershipTransfersService(config, dal, logger, eventPublisher, policy, worldsService)
+	environments := NewEnvironmentRegistry(config, logger)
+	worldsImporterService := NewWorldsImporterService(eventPublisher, dal, logger, environments, NewEnvironmentClient(logger), policy)
+	authService, err := NewAuthService(config, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize auth service")
+	}
 
 	return &Services{
-		WorldsService:         worldsService,
-		UserService:           userService,
-		WorldsImporterService: worldsImporterService,
+		WorldsService:             worldsService,
+		UserService:               userService,
+		WorldsImporterService:     worldsImporterService,
+		AuthService:               authService,
+		CollaboratorsService:      collaboratorsService,
+		InvitesService:            invitesService,
+		ModerationService:         moderationService,
+		OwnershipTransfersService: ownershipTransfersService,
 	}
 }
@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// maxTransferErrorLength caps the error saved on a world transfer, responses
+// from the target environment can be whole HTML pages
+const maxTransferErrorLength = 1024
+
+// defaultTransferPollInterval replaces a transfer_worker.poll_interval that isn't positive
+const defaultTransferPollInterval = 30 * time.Second
+
+// defaultTransferRunningTimeout replaces a transfer_worker.running_timeout
+// that isn't positive, which would make every running transfer stale
+const defaultTransferRunningTimeout = 10 * time.Minute
+
+// TransferWorker ships the worlds of transfer jobs to their target
+// environment's ingest API, see WorldsImporterService.CreateImportWorldsJob
+type TransferWorker struct {
+	config       *viper.Viper
+	dal          *dal.DAL
+	logger       logrus.FieldLogger
+	environments *EnvironmentRegistry
+	client       *EnvironmentClient
+	subscriber   *RedisEventSubscriber
+}
+
+func NewTransferWorker(
+	config *viper.Viper,
+	dal *dal.DAL,
+	logger logrus.FieldLogger,
+	environments *EnvironmentRegistry,
+	client *EnvironmentClient,
+	subscriber *RedisEventSubscriber,
+) *TransferWorker {
+	config.SetDefault("transfer_worker.concurrency", 4)
+	config.SetDefault("transfer_worker.max_attempts", 3)
+	config.SetDefault("transfer_worker.poll_interval", defaultTransferPollInterval)
+	config.SetDefault("transfer_worker.running_timeout", defaultTransferRunningTimeout)
+	return &TransferWorker{
+		config:       config,
+		dal:          dal,
+		logger:       logger,
+		environments: environments,
+		client:       client,
+		subscriber:   subscriber,
+	}
+}
+
+// Run consumes transfer events until ctx is done, then waits for the
+// transfers in flight. Queued transfers are also polled from the database,
+// which picks up retries and events published while no worker was running.
+func (w *TransferWorker) Run(ctx context.Context) error {
+	logger := w.logger.WithField("method", "Run")
+	concurrency := w.config.GetInt("transfer_worker.concurrency")
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	// time.NewTicker panics on intervals that aren't positive
+	pollInterval := w.config.GetDuration("transfer_worker.poll_interval")
+	if pollInterval <= 0 {
+		pollInterval = defaultTransferPollInterval
+	}
+	runningTimeout := w.runningTimeout()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// redis drops messages a slow subscriber doesn't read, so transfers run
+	// on a pool and the subscription loop only queues them. Transfers in
+	// flight are not interrupted on shutdown.
+	transfers := make(chan *WorldTransferRequestedEvent, concurrency)
+	workers := sync.WaitGroup{}
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		utils.SafeGo(ctx, func() {
+			defer workers.Done()
+			for event := range transfers {
+				if err := w.TransferWorld(context.WithoutCancel(ctx), event); err != nil {
+					w.logger.WithFields(logrus.Fields(event.GetLogMetadata())).WithError(err).Error("Failed to transfer world")
+				}
+			}
+		})
+	}
+
+	poller := sync.WaitGroup{}
+	poller.Add(1)
+	utils.SafeGo(ctx, func() {
+		defer poller.Done()
+		w.pollQueuedTransfers(ctx, pollInterval, runningTimeout, transfers)
+	})
+
+	logger.WithFields(logrus.Fields{
+		"concurrency":     concurrency,
+		"poll_interval":   pollInterval,
+		"running_timeout": runningTimeout,
+	}).Info("Transfer worker started")
+	err := w.subscriber.Subscribe(ctx, "worlds", func(payload []byte) {
+		event, ok := w.decodeTransferRequested(payload)
+		if !ok {
+			return
+		}
+		select {
+		case transfers <- event:
+		case <-ctx.Done():
+		}
+	})
+
+	cancel()
+	poller.Wait()
+	close(transfers)
+	workers.Wait()
+	logger.Info("Transfer worker stopped")
+	return err
+}
+
+// decodeTransferRequested keeps the world.transfer_requested events of the worlds channel
+func (w *TransferWorker) decodeTransferRequested(payload []byte) (*WorldTransferRequestedEvent, bool) {
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		w.logger.WithError(err).Warn("Ignoring malformed event")
+		return nil, false
+	}
+	if envelope.Type != WorldTransferRequestedEventType {
+		return nil, false
+	}
+
+	event := &WorldTransferRequestedEvent{}
+	if err := json.Unmarshal(payload, event); err != nil {
+		w.logger.WithError(err).Warn("Ignoring malformed transfer event")
+		return nil, false
+	}
+	return event, true
+}
+
+// runningTimeout is transfer_worker.running_timeout, or its default when it isn't positive
+func (w *TransferWorker) runningTimeout() time.Duration {
+	runningTimeout := w.config.GetDuration("transfer_worker.running_timeout")
+	if runningTimeout <= 0 {
+		return defaultTransferRunningTimeout
+	}
+	return runningTimeout
+}
+
+// pollQueuedTransfers requeues stale transfers and queues the queued ones
+// every interval until ctx is done
+func (w *TransferWorker) pollQueuedTransfers(
+	ctx context.Context,
+	interval time.Duration,
+	runningTimeout time.Duration,
+	transfers chan<- *WorldTransferRequestedEvent,
+) {
+	logger := w.logger.WithField("method", "pollQueuedTransfers")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.requeueStaleTransfers(runningTimeout); err != nil {
+			logger.WithError(err).Error("Failed to requeue stale transfers")
+		}
+		if err := w.queuePendingTransfers(ctx, transfers); err != nil {
+			logger.WithError(err).Error("Failed to queue pending transfers")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queuePendingTransfers hands the queued world transfers to the pool. Ones
+// that were already handed over by an event are skipped when claimed.
+func (w *TransferWorker) queuePendingTransfers(ctx context.Context, transfers chan<- *WorldTransferRequestedEvent) error {
+	pending, err := w.dal.WorldsTransferJobsDAL.GetWorldTransferJobsByStatus(models.WorldTransferJobStatusQueued)
+	if err != nil {
+		return err
+	}
+
+	for _, worldTransferJob := range pending {
+		event := &WorldTransferRequestedEvent{
+			Type:         WorldTransferRequestedEventType,
+			JobID:        worldTransferJob.JobId,
+			WorldID:      worldTransferJob.WorldID,
+			WorldVersion: worldTransferJob.WorldVersion,
+		}
+		select {
+		case transfers <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return nil
+}
+
+// requeueStaleTransfers puts back transfers running for longer than
+// runningTimeout, their worker most likely died
+func (w *TransferWorker) requeueStaleTransfers(runningTimeout time.Duration) error {
+	stale, err := w.dal.WorldsTransferJobsDAL.GetStaleWorldTransferJobs(time.Now().Add(-runningTimeout))
+	if err != nil {
+		return err
+	}
+
+	for i := range stale {
+		worldTransferJob := &stale[i]
+		err := w.finishAttempt(worldTransferJob, fmt.Errorf("transfer did not finish within %s", runningTimeout))
+		if err = ignoreTransferStatusChanged(err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransferWorld pushes the world at the event's version to the job's target
+// environment and records the outcome. Only queued world transfers are
+// picked up, so a world delivered twice is only sent once.
+func (w *TransferWorker) TransferWorld(ctx context.Context, event *WorldTransferRequestedEvent) error {
+	logger := w.logger.WithFields(logrus.Fields(event.GetLogMetadata())).WithField("method", "TransferWorld")
+
+	job, err := w.dal.WorldsTransferJobsDAL.GetWorldsTransferJob(event.JobID)
+	if err == pg.ErrNoRows {
+		logger.Warn("Transfer job not found, ignoring event")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	worldTransferJob, err := w.dal.WorldsTransferJobsDAL.GetWorldTransferJob(event.JobID, event.WorldID)
+	if err == pg.ErrNoRows {
+		logger.Warn("World is not part of the transfer job, ignoring event")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if worldTransferJob.Status != models.WorldTransferJobStatusQueued {
+		logger.WithField("status", worldTransferJob.Status).Debug("World transfer is not queued")
+		return nil
+	}
+
+	if job.Status == models.WorldTransferJobStatusCancelled {
+		return ignoreTransferStatusChanged(transitionWorldTransfer(w.dal, worldTransferJob, models.WorldTransferJobStatusCancelled))
+	}
+
+	err = transitionWorldTransfer(w.dal, worldTransferJob, models.WorldTransferJobStatusRunning)
+	if errors.Is(err, dal.ErrTransferStatusChanged) {
+		logger.Debug("World transfer was claimed by another worker")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if job.Status == models.WorldTransferJobStatusQueued {
+		if err := ignoreTransferStatusChanged(transitionTransferJob(w.dal, job, models.WorldTransferJobStatusRunning)); err != nil {
+			return err
+		}
+	}
+
+	logger = logger.WithField("attempt", worldTransferJob.Attempts)
+	transferErr := w.sendWorld(ctx, job, worldTransferJob)
+	if transferErr != nil {
+		logger.WithError(transferErr).Warn("World transfer attempt failed")
+	} else {
+		logger.Info("World transferred")
+	}
+
+	return w.finishAttempt(worldTransferJob, transferErr)
+}
+
+// finishAttempt records the outcome of a running world transfer. Failures
+// are queued again until transfer_worker.max_attempts, unless retrying
+// can't help.
+func (w *TransferWorker) finishAttempt(worldTransferJob *models.WorldTransferJob, transferErr error) error {
+	status := models.WorldTransferJobStatusCompleted
+	if transferErr != nil {
+		worldTransferJob.Error = transferErr.Error()
+		if len(worldTransferJob.Error) > maxTransferErrorLength {
+			worldTransferJob.Error = worldTransferJob.Error[:maxTransferErrorLength]
+		}
+
+		status = models.WorldTransferJobStatusQueued
+		if isPermanentTransferError(transferErr) || worldTransferJob.Attempts >= w.config.GetInt("transfer_worker.max_attempts") {
+			status = models.WorldTransferJobStatusFailed
+		}
+	}
+
+	if err := transitionWorldTransfer(w.dal, worldTransferJob, status); err != nil {
+		return err
+	}
+	if status == models.WorldTransferJobStatusFailed {
+		w.logger.WithFields(logrus.Fields{
+			"job_id":   worldTransferJob.JobId,
+			"world_id": worldTransferJob.WorldID,
+			"attempts": worldTransferJob.Attempts,
+		}).Error("World transfer failed")
+	}
+	if !status.IsFinished() {
+		return nil
+	}
+	return w.finishJobIfDone(worldTransferJob)
+}
+
+// isPermanentTransferError tells if retrying the transfer would fail the same way
+func isPermanentTransferError(err error) bool {
+	if errors.Is(err, ErrWorldNotFound) || errors.Is(err, ErrRevisionNotFound) || errors.Is(err, ErrUnknownEnvironment) {
+		return true
+	}
+	var responseErr *EnvironmentResponseError
+	if errors.As(err, &responseErr) {
+		return responseErr.StatusCode >= 400 && responseErr.StatusCode < 500 && responseErr.StatusCode != http.StatusTooManyRequests
+	}
+	return false
+}
+
+// sendWorld loads the world at the transfer's version and pushes it
+func (w *TransferWorker) sendWorld(ctx context.Context, job *models.WorldsTransferJob, worldTransferJob *models.WorldTransferJob) error {
+	world, err := w.worldAtVersion(worldTransferJob)
+	if err != nil {
+		return err
+	}
+	return w.pushWorld(ctx, job.TargetEnvironment, world)
+}
+
+// worldAtVersion loads the world as it was at the version the transfer was requested for
+func (w *TransferWorker) worldAtVersion(worldTransferJob *models.WorldTransferJob) (*models.World, error) {
+	revision, err := w.dal.WorldRevisionsDAL.GetWorldRevision(worldTransferJob.WorldID, worldTransferJob.WorldVersion)
+	if err == nil {
+		return revision.Snapshot, nil
+	}
+	if err != pg.ErrNoRows {
+		return nil, err
+	}
+
+	// worlds written before revisions were recorded only have their current version
+	world, err := w.dal.WorldsDAL.GetWorldByID(worldTransferJob.WorldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if world.Version != worldTransferJob.WorldVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrRevisionNotFound, worldTransferJob.WorldVersion)
+	}
+	return world, nil
+}
+
+// pushWorld sends the world to the ingest API of the target environment.
+// A conflict means the target already has a newer version, which is as
+// good as a successful transfer.
+func (w *TransferWorker) pushWorld(ctx context.Context, targetEnvironment string, world *models.World) error {
+	environment, err := w.environments.Get(targetEnvironment)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(world)
+	if err != nil {
+		return err
+	}
+
+	// the ingest API only accepts service tokens
+	ingest := *environment
+	ingest.Token = environment.ServiceToken
+
+	url := environment.BaseURL + "/internal/worlds/" + world.ID.String()
+	statusCode, responseBody, err := w.client.Do(ctx, &ingest, http.MethodPut, url, body)
+	if err != nil {
+		return err
+	}
+
+	switch statusCode {
+	case http.StatusOK, http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		w.logger.WithField("world_id", world.ID).Info("Target environment has a newer version of the world")
+		return nil
+	default:
+		return &EnvironmentResponseError{Environment: environment.Name, StatusCode: statusCode, Body: string(responseBody)}
+	}
+}
+
+// finishJobIfDone moves the job to its final status once all its worlds finished
+func (w *TransferWorker) finishJobIfDone(worldTransferJob *models.WorldTransferJob) error {
+	job, err := w.dal.WorldsTransferJobsDAL.GetWorldsTransferJob(worldTransferJob.JobId)
+	if err != nil {
+		return err
+	}
+	if job.Status.IsFinished() {
+		return nil
+	}
+
+	worldTransferJobs, err := w.dal.WorldsTransferJobsDAL.GetWorldsTransferByJob(job.ID)
+	if err != nil {
+		return err
+	}
+	statuses := make([]models.WorldTransferJobStatus, 0, len(worldTransferJobs))
+	for _, worldTransferJob := range worldTransferJobs {
+		statuses = append(statuses, worldTransferJob.Status)
+	}
+	status := models.WorldsTransferJobStatusFor(statuses)
+	if status == nil {
+		return nil
+	}
+
+	if job.Status == models.WorldTransferJobStatusQueued {
+		if err := transitionTransferJob(w.dal, job, models.WorldTransferJobStatusRunning); err != nil {
+			return ignoreTransferStatusChanged(err)
+		}
+	}
+	if err := transitionTransferJob(w.dal, job, *status); err != nil {
+		return ignoreTransferStatusChanged(err)
+	}
+	w.logger.WithFields(logrus.Fields{
+		"job_id": job.ID,
+		"status": job.Status,
+	}).Info("Transfer job finished")
+	return nil
+}
+
+// ignoreTransferStatusChanged drops the error of a transition another worker already made
+func ignoreTransferStatusChanged(err error) error {
+	if errors.Is(err, dal.ErrTransferStatusChanged) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServiceToken = "service-token"
+
+// transferStore keeps transfer jobs and world revisions in memory. Status
+// updates check the status they were read with, like the database does.
+type transferStore struct {
+	mu             sync.Mutex
+	jobs           map[uuid.UUID]models.WorldsTransferJob
+	worldTransfers []models.WorldTransferJob
+	revisions      map[uuid.UUID]models.World
+}
+
+func newTransferStore() *transferStore {
+	return &transferStore{
+		jobs:      make(map[uuid.UUID]models.WorldsTransferJob),
+		revisions: make(map[uuid.UUID]models.World),
+	}
+}
+
+func (s *transferStore) GetWorldsTransferJob(jobId uuid.UUID) (*models.WorldsTransferJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[jobId]
+	if !ok {
+		return nil, pg.ErrNoRows
+	}
+	return &job, nil
+}
+
+func (s *transferStore) UpsertJob(job *models.WorldsTransferJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *transferStore) UpdateJobStatus(job *models.WorldsTransferJob, from models.WorldTransferJobStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.jobs[job.ID].Status != from {
+		return dal.ErrTransferStatusChanged
+	}
+	s.jobs[job.ID] = *job
+	return nil
+}
+
+func (s *transferStore) GetWorldsTransferByJob(jobId uuid.UUID) ([]models.WorldTransferJob, error) {
+	return s.worldTransfersWhere(func(worldTransferJob models.WorldTransferJob) bool {
+		return worldTransferJob.JobId == jobId
+	}), nil
+}
+
+func (s *transferStore) GetWorldTransferJob(jobId, worldId uuid.UUID) (*models.WorldTransferJob, error) {
+	found := s.worldTransfersWhere(func(worldTransferJob models.WorldTransferJob) bool {
+		return worldTransferJob.JobId == jobId && worldTransferJob.WorldID == worldId
+	})
+	if len(found) == 0 {
+		return nil, pg.ErrNoRows
+	}
+	return &found[0], nil
+}
+
+func (s *transferStore) GetWorldTransferJobsByStatus(status models.WorldTransferJobStatus) ([]models.WorldTransferJob, error) {
+	return s.worldTransfersWhere(func(worldTransferJob models.WorldTransferJob) bool {
+		return worldTransferJob.Status == status
+	}), nil
+}
+
+func (s *transferStore) GetStaleWorldTransferJobs(startedBefore time.Time) ([]models.WorldTransferJob, error) {
+	return s.worldTransfersWhere(func(worldTransferJob models.WorldTransferJob) bool {
+		return worldTransferJob.Status == models.WorldTransferJobStatusRunning &&
+			worldTransferJob.StartedAt != nil && worldTransferJob.StartedAt.Before(startedBefore)
+	}), nil
+}
+
+func (s *transferStore) UpsertWorldTransferJob(worldTransferJob *models.WorldTransferJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.worldTransfers {
+		if s.worldTransfers[i].JobId == worldTransferJob.JobId && s.worldTransfers[i].WorldID == worldTransferJob.WorldID {
+			s.worldTransfers[i] = *worldTransferJob
+			return nil
+		}
+	}
+	s.worldTransfers = append(s.worldTransfers, *worldTransferJob)
+	return nil
+}
+
+func (s *transferStore) UpdateWorldTransferJobStatus(worldTransferJob *models.WorldTransferJob, from models.WorldTransferJobStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.worldTransfers {
+		if s.worldTransfers[i].JobId == worldTransferJob.JobId && s.worldTransfers[i].WorldID == worldTransferJob.WorldID {
+			if s.worldTransfers[i].Status != from {
+				return dal.ErrTransferStatusChanged
+			}
+			s.worldTransfers[i] = *worldTransferJob
+			return nil
+		}
+	}
+	return dal.ErrTransferStatusChanged
+}
+
+func (s *transferStore) worldTransfersWhere(keep func(models.WorldTransferJob) bool) []models.WorldTransferJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := []models.WorldTransferJob{}
+	for _, worldTransferJob := range s.worldTransfers {
+		if keep(worldTransferJob) {
+			found = append(found, worldTransferJob)
+		}
+	}
+	return found
+}
+
+func (s *transferStore) GetWorldRevisions(worldID uuid.UUID, before *int, limit int) ([]models.WorldRevision, error) {
+	return nil, nil
+}
+
+func (s *transferStore) GetWorldRevision(worldID uuid.UUID, version int) (*models.WorldRevision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	world, ok := s.revisions[worldID]
+	if !ok || world.Version != version {
+		return nil, pg.ErrNoRows
+	}
+	return &models.WorldRevision{WorldID: worldID, Version: world.Version, Snapshot: &world}, nil
+}
+
+// queueTransfer adds a queued job for the world and returns the event that requests it
+func (s *transferStore) queueTransfer(world models.World) *WorldTransferRequestedEvent {
+	now := time.Now()
+	job := models.WorldsTransferJob{
+		ID:                uuid.New(),
+		TargetEnvironment: "target",
+		UserID:            world.UserID,
+		Status:            models.WorldTransferJobStatusQueued,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	job.Stamp(job.Status, now)
+	s.jobs[job.ID] = job
+	return s.addToJob(job.ID, world)
+}
+
+// addToJob queues the world in an existing job and returns the event that requests it
+func (s *transferStore) addToJob(jobID uuid.UUID, world models.World) *WorldTransferRequestedEvent {
+	now := time.Now()
+	worldTransferJob := models.WorldTransferJob{
+		JobId:        jobID,
+		WorldID:      world.ID,
+		WorldVersion: world.Version,
+		Status:       models.WorldTransferJobStatusQueued,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	worldTransferJob.Stamp(worldTransferJob.Status, now)
+
+	s.revisions[world.ID] = world
+	s.worldTransfers = append(s.worldTransfers, worldTransferJob)
+	return &WorldTransferRequestedEvent{
+		Type:         WorldTransferRequestedEventType,
+		JobID:        jobID,
+		WorldID:      world.ID,
+		UserID:       world.UserID,
+		WorldVersion: world.Version,
+	}
+}
+
+func testLogger() logrus.FieldLogger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// newTestTransferWorker returns a worker whose "target" environment is served by target
+func newTestTransferWorker(t *testing.T, target http.HandlerFunc) (*TransferWorker, *transferStore) {
+	server := httptest.NewServer(target)
+	t.Cleanup(server.Close)
+
+	config := viper.New()
+	config.Set("environments.names", "target")
+	config.Set("environments.target.url", server.URL)
+	config.Set("environments.target.token", "user-token")
+	config.Set("environments.target.service_token", testServiceToken)
+	config.Set("environments.max_retries", 0)
+
+	logger := testLogger()
+	store := newTransferStore()
+	d := &dal.DAL{WorldsTransferJobsDAL: store, WorldRevisionsDAL: store}
+	worker := NewTransferWorker(config, d, logger, NewEnvironmentRegistry(config, logger), NewEnvironmentClient(logger), nil)
+	return worker, store
+}
+
+func TestTransferWorldSendsWorldAtVersion(t *testing.T) {
+	world := models.World{ID: uuid.New(), UserID: uuid.New(), Name: "Transferred", Version: 2}
+
+	received := make(chan models.World, 1)
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/internal/worlds/"+world.ID.String(), r.URL.Path)
+		assert.Equal(t, "Bearer "+testServiceToken, r.Header.Get("Authorization"))
+
+		var sent models.World
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&sent))
+		received <- sent
+		w.WriteHeader(http.StatusCreated)
+	})
+	event := store.queueTransfer(world)
+
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+
+	sent := <-received
+	require.Equal(t, world.ID, sent.ID)
+	require.Equal(t, world.Version, sent.Version)
+	require.Equal(t, world.Name, sent.Name)
+
+	worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCompleted, worldTransferJob.Status)
+	require.Equal(t, 1, worldTransferJob.Attempts)
+	require.NotNil(t, worldTransferJob.StartedAt)
+	require.NotNil(t, worldTransferJob.CompletedAt)
+
+	job, err := store.GetWorldsTransferJob(event.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCompleted, job.Status)
+	require.NotNil(t, job.CompletedAt)
+
+	// the event may be delivered again, the world is only sent once
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+	require.Empty(t, received)
+}
+
+func TestTransferWorldTargetHasNewerVersion(t *testing.T) {
+	world := models.World{ID: uuid.New(), UserID: uuid.New(), Name: "Outdated", Version: 1}
+
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	})
+	event := store.queueTransfer(world)
+
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+
+	worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCompleted, worldTransferJob.Status)
+	require.Empty(t, worldTransferJob.Error)
+}
+
+func TestIsUpToDate(t *testing.T) {
+	tests := []struct {
+		name        string
+		targetWorld *models.World
+		version     int
+		upToDate    bool
+	}{
+		{name: "missing in target", targetWorld: nil, version: 1, upToDate: false},
+		{name: "older in target", targetWorld: &models.World{Version: 1}, version: 2, upToDate: false},
+		{name: "same version in target", targetWorld: &models.World{Version: 2}, version: 2, upToDate: true},
+		{name: "newer in target", targetWorld: &models.World{Version: 3}, version: 2, upToDate: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.upToDate, isUpToDate(test.targetWorld, test.version))
+		})
+	}
+}
+
+func TestTransferWorldAttempts(t *testing.T) {
+	tests := []struct {
+		name           string
+		targetStatus   int
+		maxAttempts    int
+		expectedStatus models.WorldTransferJobStatus
+		expectedJob    models.WorldTransferJobStatus
+	}{
+		{
+			name:           "server errors are queued again while attempts are left",
+			targetStatus:   http.StatusServiceUnavailable,
+			maxAttempts:    3,
+			expectedStatus: models.WorldTransferJobStatusQueued,
+			expectedJob:    models.WorldTransferJobStatusRunning,
+		},
+		{
+			name:           "too many requests are queued again",
+			targetStatus:   http.StatusTooManyRequests,
+			maxAttempts:    3,
+			expectedStatus: models.WorldTransferJobStatusQueued,
+			expectedJob:    models.WorldTransferJobStatusRunning,
+		},
+		{
+			name:           "the last attempt fails the transfer",
+			targetStatus:   http.StatusServiceUnavailable,
+			maxAttempts:    1,
+			expectedStatus: models.WorldTransferJobStatusFailed,
+			expectedJob:    models.WorldTransferJobStatusFailed,
+		},
+		{
+			name:           "client errors are not retried",
+			targetStatus:   http.StatusBadRequest,
+			maxAttempts:    3,
+			expectedStatus: models.WorldTransferJobStatusFailed,
+			expectedJob:    models.WorldTransferJobStatusFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			world := models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1}
+			worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.targetStatus)
+			})
+			worker.config.Set("transfer_worker.max_attempts", test.maxAttempts)
+			event := store.queueTransfer(world)
+
+			require.NoError(t, worker.TransferWorld(context.Background(), event))
+
+			worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedStatus, worldTransferJob.Status)
+			require.Equal(t, 1, worldTransferJob.Attempts)
+			require.Contains(t, worldTransferJob.Error, strconv.Itoa(test.targetStatus))
+
+			job, err := store.GetWorldsTransferJob(event.JobID)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedJob, job.Status)
+		})
+	}
+}
+
+func TestTransferWorldRetriesUpToMaxAttempts(t *testing.T) {
+	world := models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1}
+	var requests int32
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	worker.config.Set("transfer_worker.max_attempts", 3)
+	event := store.queueTransfer(world)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		require.NoError(t, worker.TransferWorld(context.Background(), event))
+		worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+		require.NoError(t, err)
+		require.Equal(t, attempt, worldTransferJob.Attempts)
+	}
+
+	worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusFailed, worldTransferJob.Status)
+	require.NotNil(t, worldTransferJob.FailedAt)
+
+	// failed transfers are not picked up again
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+	require.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	job, err := store.GetWorldsTransferJob(event.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusFailed, job.Status)
+}
+
+func TestRequeueStaleTransfers(t *testing.T) {
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	worker.config.Set("transfer_worker.max_attempts", 2)
+	// a timeout that isn't positive would make every running transfer stale
+	worker.config.Set("transfer_worker.running_timeout", -time.Minute)
+	require.Equal(t, defaultTransferRunningTimeout, worker.runningTimeout())
+	worker.config.Set("transfer_worker.running_timeout", time.Minute)
+
+	// startTransfer leaves the world transfer running since startedAt, as a worker that died would
+	startTransfer := func(attempts int, startedAt time.Time) *WorldTransferRequestedEvent {
+		event := store.queueTransfer(models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1})
+		worldTransferJob, err := store.GetWorldTransferJob(event.JobID, event.WorldID)
+		require.NoError(t, err)
+		worldTransferJob.Attempts = attempts - 1
+		require.NoError(t, transitionWorldTransfer(worker.dal, worldTransferJob, models.WorldTransferJobStatusRunning))
+		worldTransferJob.StartedAt = &startedAt
+		require.NoError(t, store.UpsertWorldTransferJob(worldTransferJob))
+		return event
+	}
+	stale := startTransfer(1, time.Now().Add(-time.Hour))
+	staleOutOfAttempts := startTransfer(2, time.Now().Add(-time.Hour))
+	recent := startTransfer(1, time.Now())
+
+	require.NoError(t, worker.requeueStaleTransfers(worker.runningTimeout()))
+
+	tests := []struct {
+		name     string
+		event    *WorldTransferRequestedEvent
+		expected models.WorldTransferJobStatus
+	}{
+		{name: "stale", event: stale, expected: models.WorldTransferJobStatusQueued},
+		{name: "stale out of attempts", event: staleOutOfAttempts, expected: models.WorldTransferJobStatusFailed},
+		{name: "recent", event: recent, expected: models.WorldTransferJobStatusRunning},
+	}
+	for _, test := range tests {
+		worldTransferJob, err := store.GetWorldTransferJob(test.event.JobID, test.event.WorldID)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, worldTransferJob.Status, test.name)
+	}
+
+	worldTransferJob, err := store.GetWorldTransferJob(stale.JobID, stale.WorldID)
+	require.NoError(t, err)
+	require.Contains(t, worldTransferJob.Error, "did not finish")
+
+	// the requeued transfer runs again
+	require.NoError(t, worker.TransferWorld(context.Background(), stale))
+	worldTransferJob, err = store.GetWorldTransferJob(stale.JobID, stale.WorldID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCompleted, worldTransferJob.Status)
+	require.Equal(t, 2, worldTransferJob.Attempts)
+}
+
+func TestTransferWorldOfCancelledJob(t *testing.T) {
+	var requests int32
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusCreated)
+	})
+	world := models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1}
+	event := store.queueTransfer(world)
+
+	importer := NewWorldsImporterService(nil, worker.dal, testLogger(), worker.environments, worker.client, NewPolicy(worker.dal))
+	status, err := importer.CancelWorldsTransferJob(context.Background(), Actor{UserID: world.UserID, Role: models.RolePlayer}, event.JobID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCancelled, status.Status)
+	require.Equal(t, models.WorldTransferJobStatusCancelled, status.StatusByWorldID[world.ID])
+
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+	require.Zero(t, atomic.LoadInt32(&requests))
+
+	// worlds still queued when their job was cancelled are cancelled by the worker
+	event = store.queueTransfer(world)
+	job, err := store.GetWorldsTransferJob(event.JobID)
+	require.NoError(t, err)
+	require.NoError(t, transitionTransferJob(worker.dal, job, models.WorldTransferJobStatusCancelled))
+
+	require.NoError(t, worker.TransferWorld(context.Background(), event))
+	require.Zero(t, atomic.LoadInt32(&requests))
+	worldTransferJob, err := store.GetWorldTransferJob(event.JobID, world.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.WorldTransferJobStatusCancelled, worldTransferJob.Status)
+}
+
+func TestCancelRunningTransferJob(t *testing.T) {
+	owner := Actor{UserID: uuid.New(), Role: models.RolePlayer}
+	first := models.World{ID: uuid.New(), UserID: owner.UserID, Version: 1}
+	second := models.World{ID: uuid.New(), UserID: owner.UserID, Version: 1}
+
+	var importer *WorldsImporterService
+	var jobID uuid.UUID
+	var requests int32
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// the owner cancels while the first world is being sent
+		_, err := importer.CancelWorldsTransferJob(context.Background(), owner, jobID)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusCreated)
+	})
+	importer = NewWorldsImporterService(nil, worker.dal, testLogger(), worker.environments, worker.client, NewPolicy(worker.dal))
+	firstEvent := store.queueTransfer(first)
+	jobID = firstEvent.JobID
+	secondEvent := store.addToJob(jobID, second)
+
+	require.NoError(t, worker.TransferWorld(context.Background(), firstEvent))
+	require.NoError(t, worker.TransferWorld(context.Background(), secondEvent))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	status, err := importer.GetWorldsTransferJobStatus(context.Background(), owner, jobID)
+	require.NoError(t, err)
+	// the world in flight finishes, the job stays cancelled
+	require.Equal(t, models.WorldTransferJobStatusCancelled, status.Status)
+	require.Equal(t, models.WorldTransferJobStatusCompleted, status.StatusByWorldID[first.ID])
+	require.Equal(t, models.WorldTransferJobStatusCancelled, status.StatusByWorldID[second.ID])
+}
+
+func TestQueuePendingTransfers(t *testing.T) {
+	worker, store := newTestTransferWorker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	queued := store.queueTransfer(models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1})
+	finished := store.queueTransfer(models.World{ID: uuid.New(), UserID: uuid.New(), Version: 1})
+	require.NoError(t, worker.TransferWorld(context.Background(), finished))
+
+	transfers := make(chan *WorldTransferRequestedEvent, 2)
+	require.NoError(t, worker.queuePendingTransfers(context.Background(), transfers))
+	close(transfers)
+
+	events := []*WorldTransferRequestedEvent{}
+	for event := range transfers {
+		events = append(events, event)
+	}
+	require.Len(t, events, 1)
+	require.Equal(t, queued.JobID, events[0].JobID)
+	require.Equal(t, queued.WorldID, events[0].WorldID)
+	require.Equal(t, queued.WorldVersion, events[0].WorldVersion)
+}
@@ -1,18 +1,54 @@
 package services
 
 import (
+	"errors"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
 	"github.com/guilhermeCoutinho/worlds-api/dal"
 	"github.com/guilhermeCoutinho/worlds-api/models"
 )
 
+var ErrInvalidRole = errors.New("invalid role")
+
 type UserService struct {
-	dal *dal.DAL
+	dal    *dal.DAL
+	policy *Policy
 }
 
-func NewUserService(dal *dal.DAL) *UserService {
-	return &UserService{dal: dal}
+func NewUserService(dal *dal.DAL, policy *Policy) *UserService {
+	return &UserService{dal: dal, policy: policy}
 }
 
 func (s *UserService) CreateUser(user *models.User) error {
 	return s.dal.UserDAL.CreateUser(user)
 }
+
+// GetUserRole returns the stored role of a user. Users that are authenticated
+// but have no record yet are treated as players.
+func (s *UserService) GetUserRole(userID uuid.UUID) (models.Role, error) {
+	user, err := s.dal.UserDAL.GetUserByID(userID)
+	if err == pg.ErrNoRows {
+		return models.RolePlayer, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return user.Role, nil
+}
+
+func (s *UserService) SetUserRole(actor Actor, userID uuid.UUID, role models.Role) (*models.User, error) {
+	if err := s.policy.CanManageUsers(actor); err != nil {
+		return nil, err
+	}
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+
+	err := s.dal.UserDAL.UpdateUserRole(userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.dal.UserDAL.GetUserByID(userID)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/guilhermeCoutinho/worlds-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrRestoreWindowExpired  = errors.New("world can no longer be restored")
+	ErrInvalidDeletionConfig = errors.New("invalid deletion config")
+)
+
+// deletionRetention is how long a soft deleted world can be restored before it is purged
+func (s *WorldsService) deletionRetention() time.Duration {
+	return s.config.GetDuration("worlds.deletion_retention")
+}
+
+// purgeConfig reads the purger settings. A retention that isn't positive
+// would purge worlds as soon as they are deleted, and an interval that isn't
+// positive would make the ticker panic.
+func (s *WorldsService) purgeConfig() (time.Duration, time.Duration, error) {
+	retention := s.deletionRetention()
+	if retention <= 0 {
+		return 0, 0, fmt.Errorf("%w: worlds.deletion_retention must be positive, got %s", ErrInvalidDeletionConfig, retention)
+	}
+	interval := s.config.GetDuration("worlds.purge_interval")
+	if interval <= 0 {
+		return 0, 0, fmt.Errorf("%w: worlds.purge_interval must be positive, got %s", ErrInvalidDeletionConfig, interval)
+	}
+	return retention, interval, nil
+}
+
+// DeleteWorld soft deletes the world and removes every user currently in it.
+// The world can be restored until the retention window expires.
+func (s *WorldsService) DeleteWorld(ctx context.Context, actor Actor, worldID uuid.UUID) error {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return ErrWorldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.CanDeleteWorld(actor, world); err != nil {
+		return err
+	}
+
+	err = s.dal.WorldsDAL.SoftDeleteWorld(worldID)
+	if err == pg.ErrNoRows {
+		return ErrWorldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	removedUsers, err := s.dal.WorldsDAL.ClearWorldUsers(ctx, worldID)
+	if err != nil {
+		// the world is already gone, stale membership expires with the presence TTL
+		s.logger.WithError(err).WithField("world_id", worldID).Error("Failed to remove users from deleted world")
+	}
+	for _, userID := range removedUsers {
+		s.eventPublisher.PublishWorldLeft(context.Background(), userID, worldID)
+	}
+
+	s.eventPublisher.PublishWorldDeleted(context.Background(), world)
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":      worldID,
+		"deleted_by":    actor.UserID,
+		"removed_users": len(removedUsers),
+	}).Info("World deleted")
+
+	return nil
+}
+
+// RestoreWorld brings back a soft deleted world if it is still within the retention window
+func (s *WorldsService) RestoreWorld(actor Actor, worldID uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetDeletedWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanRestoreWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	if time.Since(world.DeletedAt) > s.deletionRetention() {
+		return nil, ErrRestoreWindowExpired
+	}
+
+	// deleted worlds don't count against the quota, so restoring one must fit
+	// in its owner's, whoever restores it
+	owner, err := s.dal.UserDAL.GetUserByID(world.UserID)
+	if err != nil {
+		return nil, err
+	}
+	limit := s.maxWorlds(owner.Role)
+
+	restored, err := s.dal.WorldsDAL.RestoreWorld(worldID, limit)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, &WorldQuotaExceededError{Limit: limit}
+	}
+
+	world.DeletedAt = time.Time{}
+	world.UpdatedAt = time.Now()
+	s.eventPublisher.PublishWorldRestored(context.Background(), world)
+
+	return world, nil
+}
+
+// PurgeDeletedWorlds permanently removes worlds deleted longer than the retention window ago
+func (s *WorldsService) PurgeDeletedWorlds() (int, error) {
+	retention, _, err := s.purgeConfig()
+	if err != nil {
+		return 0, err
+	}
+	return s.dal.WorldsDAL.PurgeDeletedWorlds(time.Now().Add(-retention))
+}
+
+// StartDeletedWorldsPurger runs PurgeDeletedWorlds every worlds.purge_interval
+// until ctx is done. It fails when the purger settings are invalid.
+func (s *WorldsService) StartDeletedWorldsPurger(ctx context.Context) error {
+	logger := s.logger.WithField("method", "StartDeletedWorldsPurger")
+	_, interval, err := s.purgeConfig()
+	if err != nil {
+		return err
+	}
+
+	utils.SafeGo(ctx, func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		logger.WithField("interval", interval).Info("Deleted worlds purger started")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := s.PurgeDeletedWorlds()
+				if err != nil {
+					logger.WithError(err).Error("Failed to purge deleted worlds")
+					continue
+				}
+				if purged > 0 {
+					logger.WithFields(logrus.Fields{"purged": purged}).Info("Purged deleted worlds")
+				}
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartDeletedWorldsPurgerRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention time.Duration
+		interval  time.Duration
+	}{
+		{name: "zero retention", retention: 0, interval: time.Hour},
+		{name: "negative retention", retention: -time.Hour, interval: time.Hour},
+		{name: "zero interval", retention: time.Hour, interval: 0},
+		{name: "negative interval", retention: time.Hour, interval: -time.Minute},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := viper.New()
+			config.Set("worlds.deletion_retention", test.retention)
+			config.Set("worlds.purge_interval", test.interval)
+			s := NewWorldsService(config, nil, testLogger(), nil, nil)
+
+			require.ErrorIs(t, s.StartDeletedWorldsPurger(context.Background()), ErrInvalidDeletionConfig)
+			_, err := s.PurgeDeletedWorlds()
+			require.ErrorIs(t, err, ErrInvalidDeletionConfig)
+		})
+	}
+}
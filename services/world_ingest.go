@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidIngestWorld = errors.New("invalid world")
+	ErrStaleWorldVersion  = errors.New("a newer version of the world is stored")
+)
+
+// StaleWorldVersionError is returned when an ingested world is older than
+// the stored one
+type StaleWorldVersionError struct {
+	CurrentVersion int
+}
+
+func (e *StaleWorldVersionError) Error() string {
+	return fmt.Sprintf("%s, current version is %d", ErrStaleWorldVersion, e.CurrentVersion)
+}
+
+func (e *StaleWorldVersionError) Unwrap() error {
+	return ErrStaleWorldVersion
+}
+
+// GetTransferredWorld returns the world to other environments checking which
+// version they have before a transfer. Visibility doesn't apply to them.
+func (s *WorldsService) GetTransferredWorld(worldID uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+// IngestWorld stores a world transferred from another environment with its
+// exact ID, owner and version. Sending the stored version again is a no-op,
+// sending an older one fails. Quotas don't apply, the world already exists
+// in the source environment.
+func (s *WorldsService) IngestWorld(ctx context.Context, worldID uuid.UUID, world *models.World) (*models.WorldIngestResult, error) {
+	if world.ID == uuid.Nil {
+		world.ID = worldID
+	}
+	if world.ID != worldID {
+		return nil, fmt.Errorf("%w: id doesn't match the url", ErrInvalidIngestWorld)
+	}
+	if world.UserID == uuid.Nil {
+		return nil, fmt.Errorf("%w: user_id is required", ErrInvalidIngestWorld)
+	}
+	if world.Version < 0 || world.MaxPlayers < 0 {
+		return nil, fmt.Errorf("%w: version and max_players can't be negative", ErrInvalidIngestWorld)
+	}
+	if world.Visibility == "" {
+		world.Visibility = models.WorldVisibilityPublic
+	}
+	if !world.Visibility.IsValid() {
+		return nil, fmt.Errorf("%w: unknown visibility %s", ErrInvalidIngestWorld, world.Visibility)
+	}
+
+	storedVersion, err := s.dal.WorldsDAL.IngestWorld(world)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"method":   "IngestWorld",
+		"world_id": world.ID,
+		"user_id":  world.UserID,
+		"version":  world.Version,
+	})
+
+	result := &models.WorldIngestResult{ID: world.ID, Version: world.Version, Created: storedVersion == nil}
+	switch {
+	case storedVersion == nil:
+		s.eventPublisher.PublishWorldCreated(context.Background(), world)
+		logger.Info("World ingested")
+	case *storedVersion > world.Version:
+		return nil, &StaleWorldVersionError{CurrentVersion: *storedVersion}
+	case *storedVersion == world.Version:
+		logger.Debug("World is already at this version")
+	default:
+		s.eventPublisher.PublishWorldUpdated(context.Background(), world, nil)
+		logger.WithField("previous_version", *storedVersion).Info("World ingested")
+	}
+
+	return result, nil
+}
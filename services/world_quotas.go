@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrWorldQuotaExceeded  = errors.New("world quota exceeded")
+	ErrCreationRateLimited = errors.New("too many worlds created")
+)
+
+// WorldQuotaExceededError is returned when a user already owns as many worlds
+// as their role allows
+type WorldQuotaExceededError struct {
+	Limit int
+}
+
+func (e *WorldQuotaExceededError) Error() string {
+	return fmt.Sprintf("%s, users with this role can own %d worlds", ErrWorldQuotaExceeded, e.Limit)
+}
+
+func (e *WorldQuotaExceededError) Unwrap() error {
+	return ErrWorldQuotaExceeded
+}
+
+// CreationRateLimitedError is returned when a user created too many worlds
+// within the rate limit window
+type CreationRateLimitedError struct {
+	Limit      int
+	Window     time.Duration
+	RetryAfter time.Duration
+}
+
+func (e *CreationRateLimitedError) Error() string {
+	return fmt.Sprintf("%s, users with this role can create %d worlds every %s", ErrCreationRateLimited, e.Limit, e.Window)
+}
+
+func (e *CreationRateLimitedError) Unwrap() error {
+	return ErrCreationRateLimited
+}
+
+// setQuotaDefaults configures quotas.<role>.max_worlds and
+// quotas.<role>.creations_per_window, 0 meaning unlimited
+func (s *WorldsService) setQuotaDefaults() {
+	s.config.SetDefault("quotas.creation_window", "1h")
+
+	defaults := map[models.Role][2]int{
+		models.RolePlayer:    {10, 10},
+		models.RoleCreator:   {100, 50},
+		models.RoleModerator: {100, 50},
+		models.RoleAdmin:     {0, 0},
+	}
+	for role, limits := range defaults {
+		s.config.SetDefault("quotas."+string(role)+".max_worlds", limits[0])
+		s.config.SetDefault("quotas."+string(role)+".creations_per_window", limits[1])
+	}
+}
+
+// quotaLimit reads one of the role's limits. Roles without quotas get the
+// player's, an unknown role must not mean unlimited.
+func (s *WorldsService) quotaLimit(role models.Role, limit string) int {
+	key := "quotas." + string(role) + "." + limit
+	if !s.config.IsSet(key) {
+		key = "quotas." + string(models.RolePlayer) + "." + limit
+	}
+	return s.config.GetInt(key)
+}
+
+func (s *WorldsService) maxWorlds(role models.Role) int {
+	return s.quotaLimit(role, "max_worlds")
+}
+
+func (s *WorldsService) creationsPerWindow(role models.Role) int {
+	return s.quotaLimit(role, "creations_per_window")
+}
+
+func (s *WorldsService) creationWindow() time.Duration {
+	return s.config.GetDuration("quotas.creation_window")
+}
+
+// checkWorldQuota fails when the actor owning one more world would go over
+// their role's limit
+func (s *WorldsService) checkWorldQuota(actor Actor) error {
+	limit := s.maxWorlds(actor.Role)
+	if limit <= 0 {
+		return nil
+	}
+
+	owned, err := s.dal.WorldsDAL.CountWorldsByOwnerID(actor.UserID)
+	if err != nil {
+		return err
+	}
+	if owned >= limit {
+		return &WorldQuotaExceededError{Limit: limit}
+	}
+	return nil
+}
+
+// reserveWorldCreation checks the actor can own one more world and counts a
+// creation against their rate limit. Creations that fail afterwards still
+// count. The world quota is checked again when the world is inserted, see
+// createWorld.
+func (s *WorldsService) reserveWorldCreation(ctx context.Context, actor Actor) error {
+	if err := s.checkWorldQuota(actor); err != nil {
+		return err
+	}
+
+	limit := s.creationsPerWindow(actor.Role)
+	if limit <= 0 {
+		return nil
+	}
+
+	window := s.creationWindow()
+	reservation, err := s.dal.WorldsDAL.ReserveWorldCreation(ctx, actor.UserID, limit, window)
+	if err != nil {
+		return err
+	}
+	if !reservation.Allowed {
+		s.logger.WithFields(logrus.Fields{
+			"user_id":     actor.UserID,
+			"creations":   reservation.Creations,
+			"retry_after": reservation.RetryAfter,
+		}).Info("World creation rate limited")
+		return &CreationRateLimitedError{Limit: limit, Window: window, RetryAfter: reservation.RetryAfter}
+	}
+	return nil
+}
+
+// createWorld inserts a world unless its owner already owns as many worlds
+// as their role allows, counting and inserting in the same transaction so
+// concurrent creations can't go over the limit
+func (s *WorldsService) createWorld(actor Actor, world *models.World) error {
+	limit := s.maxWorlds(actor.Role)
+	created, err := s.dal.WorldsDAL.CreateWorld(world, limit)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return &WorldQuotaExceededError{Limit: limit}
+	}
+	return nil
+}
+
+// GetUserQuota reports how many worlds the actor owns and created recently
+// against the limits of their role
+func (s *WorldsService) GetUserQuota(ctx context.Context, actor Actor) (*models.UserQuota, error) {
+	owned, err := s.dal.WorldsDAL.CountWorldsByOwnerID(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := s.creationWindow()
+	creations, err := s.dal.WorldsDAL.CountWorldCreations(ctx, actor.UserID, window)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserQuota{
+		Role: actor.Role,
+		Worlds: models.QuotaUsage{
+			Used:  owned,
+			Limit: s.maxWorlds(actor.Role),
+		},
+		Creations: models.CreationRateUsage{
+			QuotaUsage: models.QuotaUsage{
+				Used:  creations,
+				Limit: s.creationsPerWindow(actor.Role),
+			},
+			WindowSeconds: int(window.Seconds()),
+		},
+	}, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/dal"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaLimits(t *testing.T) {
+	config := viper.New()
+	config.Set("quotas.creator.max_worlds", 20)
+	s := NewWorldsService(config, nil, testLogger(), nil, nil)
+
+	tests := []struct {
+		role               models.Role
+		maxWorlds          int
+		creationsPerWindow int
+	}{
+		{role: models.RolePlayer, maxWorlds: 10, creationsPerWindow: 10},
+		{role: models.RoleCreator, maxWorlds: 20, creationsPerWindow: 50},
+		{role: models.RoleModerator, maxWorlds: 100, creationsPerWindow: 50},
+		{role: models.RoleAdmin, maxWorlds: 0, creationsPerWindow: 0},
+		// roles without quotas are not unlimited
+		{role: models.Role("guest"), maxWorlds: 10, creationsPerWindow: 10},
+		{role: models.Role(""), maxWorlds: 10, creationsPerWindow: 10},
+	}
+	for _, test := range tests {
+		t.Run(string(test.role), func(t *testing.T) {
+			require.Equal(t, test.maxWorlds, s.maxWorlds(test.role))
+			require.Equal(t, test.creationsPerWindow, s.creationsPerWindow(test.role))
+		})
+	}
+}
+
+// quotaWorldsDAL serves one deleted world and restores it when the limit it
+// is given leaves room for one more world
+type quotaWorldsDAL struct {
+	dal.WorldsDAL
+	deleted   *models.World
+	owned     int
+	maxWorlds int
+}
+
+func (d *quotaWorldsDAL) GetDeletedWorldByID(id uuid.UUID) (*models.World, error) {
+	world := *d.deleted
+	return &world, nil
+}
+
+func (d *quotaWorldsDAL) RestoreWorld(id uuid.UUID, maxWorlds int) (bool, error) {
+	d.maxWorlds = maxWorlds
+	return maxWorlds <= 0 || d.owned < maxWorlds, nil
+}
+
+type quotaUserDAL struct {
+	dal.UserDAL
+	users map[uuid.UUID]models.User
+}
+
+func (d *quotaUserDAL) GetUserByID(id uuid.UUID) (*models.User, error) {
+	user := d.users[id]
+	return &user, nil
+}
+
+type discardEventPublisher struct {
+	EventPublisher
+}
+
+func (discardEventPublisher) PublishWorldRestored(ctx context.Context, world *models.World) {}
+
+func TestRestoreWorldChecksOwnerQuota(t *testing.T) {
+	owner := models.User{ID: uuid.New(), Role: models.RolePlayer}
+	admin := Actor{UserID: uuid.New(), Role: models.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		actor   Actor
+		owned   int
+		allowed bool
+	}{
+		{name: "owner with quota left", actor: Actor{UserID: owner.ID, Role: owner.Role}, owned: 9, allowed: true},
+		{name: "owner at quota", actor: Actor{UserID: owner.ID, Role: owner.Role}, owned: 10, allowed: false},
+		{name: "admin restoring for an owner with quota left", actor: admin, owned: 9, allowed: true},
+		{name: "admin restoring for an owner at quota", actor: admin, owned: 10, allowed: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			worldsDAL := &quotaWorldsDAL{
+				deleted: &models.World{ID: uuid.New(), UserID: owner.ID, DeletedAt: time.Now()},
+				owned:   test.owned,
+			}
+			d := &dal.DAL{
+				WorldsDAL: worldsDAL,
+				UserDAL:   &quotaUserDAL{users: map[uuid.UUID]models.User{owner.ID: owner}},
+			}
+			s := NewWorldsService(viper.New(), d, testLogger(), discardEventPublisher{}, NewPolicy(d))
+
+			world, err := s.RestoreWorld(test.actor, worldsDAL.deleted.ID)
+			// the owner's limit applies, not the admin's
+			require.Equal(t, 10, worldsDAL.maxWorlds)
+			if test.allowed {
+				require.NoError(t, err)
+				require.True(t, world.DeletedAt.IsZero())
+				return
+			}
+			var quotaErr *WorldQuotaExceededError
+			require.ErrorAs(t, err, &quotaErr)
+			require.Equal(t, 10, quotaErr.Limit)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/go-pg/pg"
+	"github.com/google/uuid"
+	"github.com/guilhermeCoutinho/worlds-api/models"
+)
+
+var ErrRevisionNotFound = errors.New("world revision not found")
+
+// worldForHistory loads a world whose history the actor is allowed to read
+func (s *WorldsService) worldForHistory(actor Actor, worldID uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.policy.CanViewWorldHistory(actor, world); err != nil {
+		return nil, err
+	}
+	return world, nil
+}
+
+// GetWorldRevisions pages through a world's revisions, newest first
+func (s *WorldsService) GetWorldRevisions(actor Actor, worldID uuid.UUID, before *int, limit int) (*models.WorldRevisionsPage, error) {
+	if _, err := s.worldForHistory(actor, worldID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultWorldsPageSize
+	}
+	if limit > MaxWorldsPageSize {
+		limit = MaxWorldsPageSize
+	}
+
+	revisions, err := s.dal.WorldRevisionsDAL.GetWorldRevisions(worldID, before, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.WorldRevisionsPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		nextBefore := page.Revisions[limit-1].Version
+		page.NextBefore = &nextBefore
+	}
+	return page, nil
+}
+
+func (s *WorldsService) GetWorldRevision(actor Actor, worldID uuid.UUID, version int) (*models.WorldRevision, error) {
+	if _, err := s.worldForHistory(actor, worldID); err != nil {
+		return nil, err
+	}
+	return s.getWorldRevision(worldID, version)
+}
+
+func (s *WorldsService) getWorldRevision(worldID uuid.UUID, version int) (*models.WorldRevision, error) {
+	revision, err := s.dal.WorldRevisionsDAL.GetWorldRevision(worldID, version)
+	if err == pg.ErrNoRows {
+		return nil, ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// DiffWorldRevisions lists the fields that changed between two versions of a world
+func (s *WorldsService) DiffWorldRevisions(actor Actor, worldID uuid.UUID, fromVersion, toVersion int) (*models.WorldRevisionDiff, error) {
+	if _, err := s.worldForHistory(actor, worldID); err != nil {
+		return nil, err
+	}
+
+	from, err := s.getWorldRevision(worldID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.getWorldRevision(worldID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := from.Snapshot.Fields().Diff(to.Snapshot.Fields())
+	if from.Snapshot.UserID != to.Snapshot.UserID {
+		changes = append(changes, models.WorldFieldChange{Field: "user_id", From: from.Snapshot.UserID, To: to.Snapshot.UserID})
+	}
+
+	return &models.WorldRevisionDiff{
+		WorldID:     worldID,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Changes:     changes,
+	}, nil
+}
+
+// RevertWorld writes a new version of the world with the fields it had at
+// version, keeping its current visibility. History is never rewritten, the
+// revert is a revision of its own.
+func (s *WorldsService) RevertWorld(actor Actor, worldID uuid.UUID, version int, expectedVersion *int) (*models.World, error) {
+	world, err := s.worldForHistory(actor, worldID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.policy.CanUpdateWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	revision, err := s.getWorldRevision(worldID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.PatchWorld(actor, worldID, expectedVersion, func(current models.WorldFields) (models.WorldFields, error) {
+		fields := revision.Snapshot.Fields()
+		// visibility is access control rather than content, and snapshots
+		// written before it existed have none, which would read as public
+		fields.Visibility = current.Visibility
+		return fields, nil
+	})
+}
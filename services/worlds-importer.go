@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/google/uuid"
 	"github.com/guilhermeCoutinho/worlds-api/dal"
 	"github.com/guilhermeCoutinho/worlds-api/models"
@@ -14,141 +19,286 @@ type WorldsImporterService struct {
 	eventPublisher EventPublisher
 	dal            *dal.DAL
 	logger         logrus.FieldLogger
+	environments   *EnvironmentRegistry
+	client         *EnvironmentClient
+	policy         *Policy
 }
 
-func NewWorldsImporterService(eventPublisher EventPublisher, dal *dal.DAL) *WorldsImporterService {
-	return &WorldsImporterService{eventPublisher: eventPublisher, dal: dal}
+func NewWorldsImporterService(
+	eventPublisher EventPublisher,
+	dal *dal.DAL,
+	logger logrus.FieldLogger,
+	environments *EnvironmentRegistry,
+	client *EnvironmentClient,
+	policy *Policy,
+) *WorldsImporterService {
+	return &WorldsImporterService{
+		eventPublisher: eventPublisher,
+		dal:            dal,
+		logger:         logger,
+		environments:   environments,
+		client:         client,
+		policy:         policy,
+	}
 }
 
-func (s *WorldsImporterService) MakeRequest(ctx context.Context, url string) (*models.World, error) {
-	return nil, nil
+// MakeRequest fetches a world from the internal API of the target
+// environment. It returns nil without an error when the world doesn't exist
+// there yet.
+func (s *WorldsImporterService) MakeRequest(ctx context.Context, targetEnvironment string, url string) (*models.World, error) {
+	environment, err := s.environments.Get(targetEnvironment)
+	if err != nil {
+		return nil, err
+	}
+
+	// the internal API only accepts service tokens
+	internal := *environment
+	internal.Token = environment.ServiceToken
+
+	statusCode, body, err := s.client.Do(ctx, &internal, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch statusCode {
+	case http.StatusOK:
+		world := &models.World{}
+		if err := json.Unmarshal(body, world); err != nil {
+			return nil, fmt.Errorf("invalid world from environment %s: %w", environment.Name, err)
+		}
+		return world, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, &EnvironmentResponseError{Environment: environment.Name, StatusCode: statusCode, Body: string(body)}
+	}
 }
 
-func (s *WorldsImporterService) GetEnvironmentURL(ctx context.Context, worldId uuid.UUID, targetEnvironment string) string {
-	return ""
+func (s *WorldsImporterService) GetEnvironmentURL(ctx context.Context, worldId uuid.UUID, targetEnvironment string) (string, error) {
+	environment, err := s.environments.Get(targetEnvironment)
+	if err != nil {
+		return "", err
+	}
+	return environment.BaseURL + "/internal/worlds/" + worldId.String(), nil
 }
 
-func (s *WorldsImporterService) CreateImportWorldsJob(ctx context.Context, userId uuid.UUID, worlds []uuid.UUID, targetEnvironment string) (*models.WorldTransferJobStatusDTO, error) {
-	response := &models.WorldTransferJobStatusDTO{
-		JobId:           uuid.New(),
-		Status:          models.WorldTransferJobStatusCreated,
-		StatusByWorldID: make(map[uuid.UUID]models.WorldTransferJobStatus),
+// getTargetWorld fetches the world from the target environment, nil when it isn't there
+func (s *WorldsImporterService) getTargetWorld(ctx context.Context, worldId uuid.UUID, targetEnvironment string) (*models.World, error) {
+	url, err := s.GetEnvironmentURL(ctx, worldId, targetEnvironment)
+	if err != nil {
+		return nil, err
 	}
+	return s.MakeRequest(ctx, targetEnvironment, url)
+}
+
+// isUpToDate tells if the target environment has the world at version or newer
+func isUpToDate(targetWorld *models.World, version int) bool {
+	return targetWorld != nil && targetWorld.Version >= version
+}
 
-	allWorldsUpToDate := true
-	worldCurrentVersion := make(map[uuid.UUID]int)
+var ErrTransferJobNotFound = errors.New("transfer job not found")
+
+// CreateImportWorldsJob copies the actor's worlds to the target environment,
+// skipping the ones that are already up to date there. The transfer worker
+// picks up the queued worlds.
+func (s *WorldsImporterService) CreateImportWorldsJob(ctx context.Context, actor Actor, worlds []uuid.UUID, targetEnvironment string) (*models.WorldTransferJobStatusDTO, error) {
+	if _, err := s.environments.Get(targetEnvironment); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &models.WorldsTransferJob{
+		ID:                uuid.New(),
+		TargetEnvironment: targetEnvironment,
+		UserID:            actor.UserID,
+		Status:            models.WorldTransferJobStatusCompleted,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	worldTransferJobs := []models.WorldTransferJob{}
+	pendingWorlds := []*models.World{}
 	for _, worldID := range worlds {
 		world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+		if err == pg.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrWorldNotFound, worldID)
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		worldCurrentVersion[worldID] = world.Version
+		if err := s.policy.CanTransferWorld(actor, world); err != nil {
+			return nil, err
+		}
 
-		targetEnvironmentWorld, err := s.MakeRequest(ctx, s.GetEnvironmentURL(ctx, worldID, targetEnvironment))
+		targetEnvironmentWorld, err := s.getTargetWorld(ctx, worldID, targetEnvironment)
 		if err != nil {
 			return nil, err
 		}
 
-		if targetEnvironmentWorld.Version <= world.Version {
+		worldTransferJob := models.WorldTransferJob{
+			JobId:        job.ID,
+			WorldID:      worldID,
+			WorldVersion: world.Version,
+			Status:       models.WorldTransferJobStatusQueued,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if isUpToDate(targetEnvironmentWorld, world.Version) {
 			s.logger.WithField("world_id", worldID).Info("World version is already up to date")
-			response.StatusByWorldID[worldID] = models.WorldTransferJobStatusCompleted
-			continue
+			worldTransferJob.Status = models.WorldTransferJobStatusCompleted
+		} else {
+			pendingWorlds = append(pendingWorlds, world)
+			job.Status = models.WorldTransferJobStatusQueued
 		}
+		worldTransferJob.Stamp(worldTransferJob.Status, now)
+		worldTransferJobs = append(worldTransferJobs, worldTransferJob)
+	}
+
+	job.Stamp(job.Status, now)
+	if err := s.dal.WorldsTransferJobsDAL.UpsertJob(job); err != nil {
+		return nil, err
+	}
+
+	for i := range worldTransferJobs {
+		if err := s.dal.WorldsTransferJobsDAL.UpsertWorldTransferJob(&worldTransferJobs[i]); err != nil {
+			return nil, err
+		}
+	}
 
-		s.eventPublisher.PublishWorldTransferRequested(ctx, &WorldTransferRequestedEvent{
+	// the rows must exist before the transfer worker picks the events up
+	for _, world := range pendingWorlds {
+		s.eventPublisher.PublishWorldTransferRequested(context.Background(), &WorldTransferRequestedEvent{
+			JobID:             job.ID,
 			WorldID:           world.ID,
 			UserID:            world.UserID,
 			WorldVersion:      world.Version,
 			TargetEnvironment: targetEnvironment,
 		})
-		response.StatusByWorldID[worldID] = models.WorldTransferJobStatusCreated
-		allWorldsUpToDate = false
 	}
 
-	err := s.dal.WorldsTransferJobsDAL.UpsertJob(&models.WorldsTransferJob{
-		ID:        response.JobId,
-		Status:    response.Status,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	})
+	return worldsTransferJobStatusDTO(job, worldTransferJobs), nil
+}
+
+// getTransferJob loads a job the actor is allowed to see
+func (s *WorldsImporterService) getTransferJob(actor Actor, jobId uuid.UUID) (*models.WorldsTransferJob, error) {
+	job, err := s.dal.WorldsTransferJobsDAL.GetWorldsTransferJob(jobId)
+	if err == pg.ErrNoRows {
+		return nil, ErrTransferJobNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if allWorldsUpToDate {
-		response.Status = models.WorldTransferJobStatusCompleted
-	} else {
-		for worldId, worldStatus := range response.StatusByWorldID {
-			s.dal.WorldsTransferJobsDAL.UpsertWorldTransferJob(&models.WorldTransferJob{
-				WorldID:      worldId,
-				JobId:        response.JobId,
-				WorldVersion: worldCurrentVersion[worldId],
-				Status:       worldStatus,
-			})
-		}
+	if err := s.policy.CanViewTransferJob(actor, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetWorldsTransferJobStatus reports the job's progress, only to the user
+// who started it and admins
+func (s *WorldsImporterService) GetWorldsTransferJobStatus(ctx context.Context, actor Actor, jobId uuid.UUID) (*models.WorldTransferJobStatusDTO, error) {
+	job, err := s.getTransferJob(actor, jobId)
+	if err != nil {
+		return nil, err
 	}
 
-	return response, nil
+	worldTransferJobs, err := s.dal.WorldsTransferJobsDAL.GetWorldsTransferByJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+	return worldsTransferJobStatusDTO(job, worldTransferJobs), nil
 }
 
-func (s *WorldsImporterService) GetAndUpdateWorldsTransferJobStatus(ctx context.Context, jobId uuid.UUID) (*models.WorldTransferJobStatusDTO, error) {
-	job, err := s.dal.WorldsTransferJobsDAL.GetWorldsTransferJob(jobId)
+// CancelWorldsTransferJob cancels the worlds of the job that haven't started
+// yet. Worlds being transferred finish, the job is cancelled either way.
+func (s *WorldsImporterService) CancelWorldsTransferJob(ctx context.Context, actor Actor, jobId uuid.UUID) (*models.WorldTransferJobStatusDTO, error) {
+	job, err := s.getTransferJob(actor, jobId)
 	if err != nil {
 		return nil, err
 	}
 
-	// if completed, no need to fetch individual world statuses, just return
-	if job.Status == models.WorldTransferJobStatusCompleted {
-		return &models.WorldTransferJobStatusDTO{
-			JobId:           jobId,
-			Status:          job.Status,
-			StatusByWorldID: nil,
-		}, nil
+	err = transitionTransferJob(s.dal, job, models.WorldTransferJobStatusCancelled)
+	if errors.Is(err, dal.ErrTransferStatusChanged) {
+		// a worker started the job meanwhile, try again with its new status
+		if job, err = s.getTransferJob(actor, jobId); err != nil {
+			return nil, err
+		}
+		err = transitionTransferJob(s.dal, job, models.WorldTransferJobStatusCancelled)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	statusByWorldID := make(map[uuid.UUID]models.WorldTransferJobStatus)
 	worldTransferJobs, err := s.dal.WorldsTransferJobsDAL.GetWorldsTransferByJob(jobId)
 	if err != nil {
 		return nil, err
 	}
-	for _, worldTransferJob := range worldTransferJobs {
-		statusByWorldID[worldTransferJob.WorldID] = worldTransferJob.Status
-		if worldTransferJob.Status == models.WorldTransferJobStatusCompleted {
+	for i := range worldTransferJobs {
+		worldTransferJob := &worldTransferJobs[i]
+		if worldTransferJob.Status != models.WorldTransferJobStatusQueued {
+			continue
+		}
+		err := transitionWorldTransfer(s.dal, worldTransferJob, models.WorldTransferJobStatusCancelled)
+		if errors.Is(err, dal.ErrTransferStatusChanged) {
+			// a worker just started it, the row we have is stale
+			if current, err := s.dal.WorldsTransferJobsDAL.GetWorldTransferJob(jobId, worldTransferJob.WorldID); err == nil {
+				*worldTransferJob = *current
+			}
 			continue
 		}
-
-		worldAtTargetEnvironment, err := s.MakeRequest(ctx, s.GetEnvironmentURL(ctx, worldTransferJob.WorldID, job.TargetEnvironment))
 		if err != nil {
-			s.logger.WithField("world_id", worldTransferJob.WorldID).Error("Error making request to target environment")
 			return nil, err
 		}
-		if worldAtTargetEnvironment.Version <= worldTransferJob.WorldVersion {
-			statusByWorldID[worldTransferJob.WorldID] = models.WorldTransferJobStatusCompleted
-		}
 	}
 
-	areAllWorldsUpToDate := true
-	for _, worldStatus := range statusByWorldID {
-		if worldStatus != models.WorldTransferJobStatusCompleted {
-			areAllWorldsUpToDate = false
-			break
-		}
+	s.logger.WithFields(logrus.Fields{
+		"job_id":  jobId,
+		"user_id": actor.UserID,
+	}).Info("Transfer job cancelled")
+
+	return worldsTransferJobStatusDTO(job, worldTransferJobs), nil
+}
+
+// transitionTransferJob moves the job to status and saves it, it fails with
+// dal.ErrTransferStatusChanged when the job changed since it was read
+func transitionTransferJob(d *dal.DAL, job *models.WorldsTransferJob, status models.WorldTransferJobStatus) error {
+	from := job.Status
+	if err := job.TransitionTo(status, time.Now()); err != nil {
+		return err
 	}
+	return d.WorldsTransferJobsDAL.UpdateJobStatus(job, from)
+}
 
-	// save it as completed
-	if areAllWorldsUpToDate {
-		job.Status = models.WorldTransferJobStatusCompleted
-		job.UpdatedAt = time.Now()
-		err = s.dal.WorldsTransferJobsDAL.UpsertJob(job)
-		if err != nil {
-			s.logger.WithField("job_id", jobId).Error("Error updating job status")
-		}
+// transitionWorldTransfer moves the world transfer to status and saves it,
+// it fails with dal.ErrTransferStatusChanged when the world transfer changed
+// since it was read
+func transitionWorldTransfer(d *dal.DAL, worldTransferJob *models.WorldTransferJob, status models.WorldTransferJobStatus) error {
+	from := worldTransferJob.Status
+	if err := worldTransferJob.TransitionTo(status, time.Now()); err != nil {
+		return err
 	}
+	return d.WorldsTransferJobsDAL.UpdateWorldTransferJobStatus(worldTransferJob, from)
+}
 
-	return &models.WorldTransferJobStatusDTO{
-		JobId:           jobId,
-		Status:          job.Status,
-		StatusByWorldID: statusByWorldID,
-	}, nil
+func worldsTransferJobStatusDTO(job *models.WorldsTransferJob, worldTransferJobs []models.WorldTransferJob) *models.WorldTransferJobStatusDTO {
+	response := &models.WorldTransferJobStatusDTO{
+		JobId:              job.ID,
+		Status:             job.Status,
+		StatusByWorldID:    make(map[uuid.UUID]models.WorldTransferJobStatus),
+		Worlds:             make(map[uuid.UUID]models.WorldTransferDTO),
+		TransferTimestamps: job.TransferTimestamps,
+	}
+	for _, worldTransferJob := range worldTransferJobs {
+		response.StatusByWorldID[worldTransferJob.WorldID] = worldTransferJob.Status
+		response.Worlds[worldTransferJob.WorldID] = models.WorldTransferDTO{
+			Status:             worldTransferJob.Status,
+			Version:            worldTransferJob.WorldVersion,
+			Attempts:           worldTransferJob.Attempts,
+			Error:              worldTransferJob.Error,
+			TransferTimestamps: worldTransferJob.TransferTimestamps,
+		}
+	}
+	return response
 }
@@ -2,10 +2,14 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/google/uuid"
 	"github.com/guilhermeCoutinho/worlds-api/dal"
 	"github.com/guilhermeCoutinho/worlds-api/models"
@@ -18,6 +22,7 @@ type WorldsService struct {
 	logger         logrus.FieldLogger
 	config         *viper.Viper
 	eventPublisher EventPublisher
+	policy         *Policy
 }
 
 func NewWorldsService(
@@ -25,102 +30,424 @@ func NewWorldsService(
 	dal *dal.DAL,
 	logger logrus.FieldLogger,
 	eventPublisher EventPublisher,
+	policy *Policy,
 ) *WorldsService {
-	return &WorldsService{
+	config.SetDefault("presence.ttl", "90s")
+	config.SetDefault("presence.sweep_interval", "15s")
+	config.SetDefault("presence.sweep_batch_size", 500)
+	config.SetDefault("worlds.deletion_retention", "720h")
+	config.SetDefault("worlds.purge_interval", "1h")
+	config.SetDefault("worlds.require_if_match", false)
+
+	s := &WorldsService{
 		dal:            dal,
 		logger:         logger,
 		config:         config,
 		eventPublisher: eventPublisher,
+		policy:         policy,
 	}
+	s.setQuotaDefaults()
+	return s
 }
 
-func (s *WorldsService) GetWorlds() ([]models.World, error) {
-	worlds, err := s.dal.WorldsDAL.GetWorlds()
+const (
+	DefaultWorldsPageSize = 20
+	MaxWorldsPageSize     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListWorlds pages through the worlds actor may see. actor is nil for anonymous requests.
+func (s *WorldsService) ListWorlds(ctx context.Context, actor *Actor, query *models.WorldsQuery) (*models.WorldsPage, error) {
+	query.Viewer = s.policy.WorldsViewer(actor)
+	if query.Sort == "" {
+		query.Sort = models.WorldsSortCreatedAt
+		query.Descending = true
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultWorldsPageSize
+	}
+	if query.Limit > MaxWorldsPageSize {
+		query.Limit = MaxWorldsPageSize
+	}
+	if query.After != nil {
+		if err := validateWorldsCursor(query); err != nil {
+			return nil, err
+		}
+	}
+
+	worlds, err := s.dal.WorldsDAL.ListWorlds(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	if worlds == nil {
-		return []models.World{}, nil
+
+	page := &models.WorldsPage{Worlds: worlds}
+	if len(worlds) > query.Limit {
+		page.Worlds = worlds[:query.Limit]
+		nextCursor, err := EncodeWorldsCursor(newWorldsCursor(query, &page.Worlds[query.Limit-1]))
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &nextCursor
 	}
-	return worlds, nil
+
+	return page, nil
 }
 
-func (s *WorldsService) GetWorldByID(id uuid.UUID) (*models.World, error) {
-	world, err := s.dal.WorldsDAL.GetWorldByID(id)
+// validateWorldsCursor checks that the cursor was issued for the query's
+// sort order and that its value can be compared to the sort column
+func validateWorldsCursor(query *models.WorldsQuery) error {
+	cursor := query.After
+	if cursor.Sort != query.Sort || cursor.Descending != query.Descending {
+		return fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	var err error
+	switch query.Sort {
+	case models.WorldsSortCreatedAt, models.WorldsSortUpdatedAt:
+		_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	case models.WorldsSortUserCount:
+		var userCount int
+		userCount, err = strconv.Atoi(cursor.Value)
+		if err == nil && userCount < 0 {
+			err = errors.New("negative user count")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return nil
+}
+
+func newWorldsCursor(query *models.WorldsQuery, last *models.World) *models.WorldsCursor {
+	cursor := &models.WorldsCursor{
+		Sort:       query.Sort,
+		Descending: query.Descending,
+		ID:         last.ID,
+	}
+
+	switch query.Sort {
+	case models.WorldsSortCreatedAt:
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	case models.WorldsSortUpdatedAt:
+		cursor.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+	case models.WorldsSortName:
+		cursor.Value = last.Name
+	case models.WorldsSortUserCount:
+		if last.UserCount != nil {
+			cursor.Value = strconv.Itoa(*last.UserCount)
+		}
+	}
+
+	return cursor
+}
+
+// EncodeWorldsCursor turns a cursor into the opaque token handed to clients
+func EncodeWorldsCursor(cursor *models.WorldsCursor) (string, error) {
+	cursorJSON, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(cursorJSON), nil
+}
+
+func DecodeWorldsCursor(token string) (*models.WorldsCursor, error) {
+	cursorJSON, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &models.WorldsCursor{}
+	if err := json.Unmarshal(cursorJSON, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (s *WorldsService) SearchWorlds(actor *Actor, text string, limit, offset int) (*models.WorldSearchPage, error) {
+	if limit <= 0 {
+		limit = DefaultWorldsPageSize
+	}
+	if limit > MaxWorldsPageSize {
+		limit = MaxWorldsPageSize
+	}
+
+	results, err := s.dal.WorldsDAL.SearchWorlds(text, s.policy.WorldsViewer(actor), limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	return world, nil
+
+	page := &models.WorldSearchPage{Results: results}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		nextOffset := offset + limit
+		page.NextOffset = &nextOffset
+	}
+
+	return page, nil
 }
 
-func (s *WorldsService) GetWorldsByOwnerID(ownerID uuid.UUID) ([]models.World, error) {
-	worlds, err := s.dal.WorldsDAL.GetWorldsByOwnerID(ownerID)
+func (s *WorldsService) GetWorldByID(ctx context.Context, actor Actor, id uuid.UUID) (*models.World, error) {
+	world, err := s.dal.WorldsDAL.GetWorldByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// private worlds look like they don't exist to non-members
+	err = s.policy.CanViewWorld(actor, world)
+	if err == ErrForbidden {
+		return nil, ErrWorldNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	if worlds == nil {
-		return []models.World{}, nil
+
+	userCount, err := s.dal.WorldsDAL.GetWorldUserCount(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return worlds, nil
+	world.UserCount = &userCount
+
+	return world, nil
+}
+
+// GetActiveWorlds lists worlds that currently have players, most populated first
+func (s *WorldsService) GetActiveWorlds(ctx context.Context, actor *Actor, limit int, after *models.WorldsCursor) (*models.WorldsPage, error) {
+	return s.ListWorlds(ctx, actor, &models.WorldsQuery{
+		Sort:            models.WorldsSortUserCount,
+		Descending:      true,
+		Limit:           limit,
+		After:           after,
+		WithPlayersOnly: true,
+	})
 }
 
-func (s *WorldsService) CreateWorld(ownerID uuid.UUID, name, description string) (*models.World, error) {
+// CreateWorld creates a world owned by the actor, within the quotas of their
+// role. An empty visibility means public.
+func (s *WorldsService) CreateWorld(ctx context.Context, actor Actor, name, description string, maxPlayers int, visibility models.WorldVisibility) (*models.World, error) {
+	if visibility == "" {
+		visibility = models.WorldVisibilityPublic
+	}
+
+	if err := s.reserveWorldCreation(ctx, actor); err != nil {
+		return nil, err
+	}
+
 	world := &models.World{
 		ID:          uuid.New(),
-		UserID:      ownerID,
+		UserID:      actor.UserID,
 		Name:        name,
 		Description: description,
+		MaxPlayers:  maxPlayers,
+		Visibility:  visibility,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	err := s.dal.WorldsDAL.CreateWorld(world)
+	if err := s.createWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	s.eventPublisher.PublishWorldCreated(context.Background(), world)
+
+	return world, nil
+}
+
+// ForkWorld copies a world into a new one owned by the actor. The fork keeps
+// a reference to the world and version it was copied from. name replaces the
+// source world's name when it is not empty. Forks count against the actor's
+// quotas like new worlds.
+func (s *WorldsService) ForkWorld(ctx context.Context, actor Actor, sourceID uuid.UUID, name string) (*models.World, error) {
+	source, err := s.dal.WorldsDAL.GetWorldByID(sourceID)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if err := s.policy.CanForkWorld(actor, source); err != nil {
+		return nil, err
+	}
+
+	if err := s.reserveWorldCreation(ctx, actor); err != nil {
+		return nil, err
+	}
+
+	forkedFromVersion := source.Version
+	world := &models.World{
+		ID:                uuid.New(),
+		UserID:            actor.UserID,
+		ForkedFromID:      &source.ID,
+		ForkedFromVersion: &forkedFromVersion,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+	world.SetFields(source.Fields())
+	if name != "" {
+		world.Name = name
+	}
+
+	if err := s.createWorld(actor, world); err != nil {
+		return nil, err
+	}
+
 	s.eventPublisher.PublishWorldCreated(context.Background(), world)
+	s.eventPublisher.PublishWorldForked(context.Background(), world)
+
+	s.logger.WithFields(logrus.Fields{
+		"world_id":            world.ID,
+		"forked_from_id":      source.ID,
+		"forked_from_version": forkedFromVersion,
+		"user_id":             actor.UserID,
+	}).Info("World forked")
 
 	return world, nil
 }
 
-func (s *WorldsService) UpdateWorld(userId, worldId uuid.UUID, name, description string) (*models.World, error) {
+var ErrWorldVersionConflict = errors.New("world was modified by someone else")
+
+// WorldVersionConflictError is returned when an update was based on an
+// outdated version of the world. It carries the version the client should
+// re-read.
+type WorldVersionConflictError struct {
+	CurrentVersion int
+}
+
+func (e *WorldVersionConflictError) Error() string {
+	return fmt.Sprintf("%s, current version is %d", ErrWorldVersionConflict, e.CurrentVersion)
+}
+
+func (e *WorldVersionConflictError) Unwrap() error {
+	return ErrWorldVersionConflict
+}
+
+// RequireIfMatch reports whether world updates must be conditional on a version
+func (s *WorldsService) RequireIfMatch() bool {
+	return s.config.GetBool("worlds.require_if_match")
+}
+
+// UpdateWorld replaces the world's name and description. maxPlayers and
+// visibility are only changed when they are not nil. When expectedVersion is
+// not nil the update only succeeds if the world is still at that version.
+func (s *WorldsService) UpdateWorld(actor Actor, worldId uuid.UUID, expectedVersion *int, name, description string, maxPlayers *int, visibility *models.WorldVisibility) (*models.World, error) {
+	return s.PatchWorld(actor, worldId, expectedVersion, func(fields models.WorldFields) (models.WorldFields, error) {
+		fields.Name = name
+		fields.Description = description
+		if maxPlayers != nil {
+			fields.MaxPlayers = *maxPlayers
+		}
+		if visibility != nil {
+			fields.Visibility = *visibility
+		}
+		return fields, nil
+	})
+}
+
+// WorldPatchFunc computes a world's new fields from its current ones
+type WorldPatchFunc func(current models.WorldFields) (models.WorldFields, error)
+
+// PatchWorld applies patch to the stored world. The write is conditional on
+// the version that was read, so a concurrent update is reported as a
+// version conflict instead of being overwritten. Errors returned by patch
+// are passed through unchanged. When patch changes nothing the world is
+// returned as is.
+func (s *WorldsService) PatchWorld(actor Actor, worldId uuid.UUID, expectedVersion *int, patch WorldPatchFunc) (*models.World, error) {
 	world, err := s.dal.WorldsDAL.GetWorldByID(worldId)
+	if err == pg.ErrNoRows {
+		return nil, ErrWorldNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	world.Name = name
-	world.Description = description
-	world.UpdatedAt = time.Now()
+	if err := s.policy.CanUpdateWorld(actor, world); err != nil {
+		return nil, err
+	}
+
+	if expectedVersion != nil && *expectedVersion != world.Version {
+		return nil, &WorldVersionConflictError{CurrentVersion: world.Version}
+	}
 
-	if world.UserID != userId {
-		return nil, errors.New("unauthorized")
+	current := world.Fields()
+	patched, err := patch(current)
+	if err != nil {
+		return nil, err
+	}
+	if patched.Visibility == "" {
+		patched.Visibility = models.WorldVisibilityPublic
+	}
+	if patched.Visibility != current.Visibility {
+		if err := s.policy.CanChangeVisibility(actor, world); err != nil {
+			return nil, err
+		}
 	}
 
-	err = s.dal.WorldsDAL.UpdateWorld(world)
+	// nothing to write, a no-op update doesn't make a new version
+	changedFields := patched.ChangedFields(current)
+	if len(changedFields) == 0 {
+		return world, nil
+	}
+	world.SetFields(patched)
+	world.UpdatedAt = time.Now()
+
+	err = s.dal.WorldsDAL.UpdateWorld(world, actor.UserID)
+	if err == dal.ErrVersionConflict {
+		return nil, s.versionConflict(worldId)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	s.eventPublisher.PublishWorldUpdated(context.Background(), world)
+	s.eventPublisher.PublishWorldUpdated(context.Background(), world, changedFields)
 
 	return world, nil
 }
 
-func (s *WorldsService) JoinWorld(ctx context.Context, userID, worldID uuid.UUID) error {
+// versionConflict builds the conflict error for a world that was updated concurrently
+func (s *WorldsService) versionConflict(worldID uuid.UUID) error {
+	current, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err != nil {
+		return err
+	}
+	return &WorldVersionConflictError{CurrentVersion: current.Version}
+}
+
+var (
+	ErrWorldNotFound = errors.New("world not found")
+	ErrWorldFull     = errors.New("world is full")
+)
+
+func (s *WorldsService) JoinWorld(ctx context.Context, actor Actor, worldID uuid.UUID) error {
+	userID := actor.UserID
 	world, err := s.dal.WorldsDAL.GetWorldByID(worldID)
+	if err == pg.ErrNoRows {
+		return ErrWorldNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// private worlds look like they don't exist to users who can't join them
+	err = s.policy.CanJoinWorld(actor, world)
+	if err == ErrForbidden {
+		return ErrWorldNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("world not found: %w", err)
+		return err
 	}
-	if world == nil {
-		return errors.New("world not found")
+
+	if err := s.policy.CheckNotBanned(actor, world); err != nil {
+		return err
 	}
 
-	err = s.dal.WorldsDAL.JoinWorld(ctx, userID, worldID)
+	joined, err := s.dal.WorldsDAL.JoinWorld(ctx, userID, world, s.presenceTTL())
 	if err != nil {
 		return fmt.Errorf("failed to join world: %w", err)
 	}
+	if !joined {
+		return ErrWorldFull
+	}
 
 	s.logger.WithFields(logrus.Fields{
 		"user_id":  userID,
@@ -130,6 +457,32 @@ func (s *WorldsService) JoinWorld(ctx context.Context, userID, worldID uuid.UUID
 	return nil
 }
 
+var ErrUserNotInWorld = errors.New("user is not in this world")
+
+// LeaveWorld removes the user from worldID, or from their current world when
+// worldID is uuid.Nil. Leaving when not in any world is not an error.
+func (s *WorldsService) LeaveWorld(ctx context.Context, userID, worldID uuid.UUID) error {
+	leftWorldID, err := s.dal.WorldsDAL.LeaveWorld(ctx, userID, worldID)
+	if err != nil {
+		return fmt.Errorf("failed to leave world: %w", err)
+	}
+	if leftWorldID == uuid.Nil {
+		if worldID != uuid.Nil {
+			return ErrUserNotInWorld
+		}
+		return nil
+	}
+
+	s.eventPublisher.PublishWorldLeft(context.Background(), userID, leftWorldID)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"world_id": leftWorldID,
+	}).Info("User left world")
+
+	return nil
+}
+
 func (s *WorldsService) GetUserCurrentWorld(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
 	return s.dal.WorldsDAL.GetUserCurrentWorld(ctx, userID)
 }
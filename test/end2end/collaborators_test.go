@@ -0,0 +1,99 @@
+package end2end
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollaboratorsCanEditWorld(t *testing.T) {
+	owner := uuid.New().String()
+	editor := uuid.New().String()
+	viewer := uuid.New().String()
+	for _, userID := range []string{owner, editor, viewer} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	editorHeaders := map[string]string{"Authorization": "Bearer " + editor}
+	viewerHeaders := map[string]string{"Authorization": "Bearer " + viewer}
+
+	newWorld := map[string]string{
+		"name":        "Team World",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/collaborators", map[string]string{"user_id": editor, "role": "editor"}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/collaborators", map[string]string{"user_id": viewer, "role": "viewer"}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// editors cannot manage collaborators
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID+"/collaborators/"+viewer, nil, editorHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	collaborators, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/collaborators", nil, viewerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, collaborators, 3)
+
+	updatedWorld := map[string]string{
+		"name":        "Team World v2",
+		"description": "edited by a collaborator",
+	}
+	updated, resp := DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+worldID, updatedWorld, editorHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Team World v2", updated["name"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, updatedWorld, viewerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID+"/collaborators/"+editor, nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, updatedWorld, editorHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestListWorldMembers(t *testing.T) {
+	owner := uuid.New().String()
+	player := uuid.New().String()
+	for _, userID := range []string{owner, player} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+
+	newWorld := map[string]string{
+		"name":        "Crowded World",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	type membersPage struct {
+		Members    []map[string]interface{} `json:"members"`
+		NextCursor *string                  `json:"next_cursor"`
+	}
+	page, resp := DoRequest[membersPage](t, http.MethodGet, "/worlds/"+worldID+"/members", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Members, 1)
+	require.Equal(t, player, page.Members[0]["id"])
+	require.Nil(t, page.NextCursor)
+
+	// players in a world can't list who else is there unless they are collaborators
+	_, resp = DoRequest[membersPage](t, http.MethodGet, "/worlds/"+worldID+"/members", nil, playerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,165 @@
+package end2end
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldInvites(t *testing.T) {
+	owner := uuid.New().String()
+	invitee := uuid.New().String()
+	linkUser := uuid.New().String()
+	stranger := uuid.New().String()
+	for _, userID := range []string{owner, invitee, linkUser, stranger} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	inviteeHeaders := map[string]string{"Authorization": "Bearer " + invitee}
+	linkUserHeaders := map[string]string{"Authorization": "Bearer " + linkUser}
+	strangerHeaders := map[string]string{"Authorization": "Bearer " + stranger}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Invite Only",
+		"description": "from e2e test",
+		"visibility":  "private",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/invites", nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// an invite for a specific user
+	userInvite, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/invites", map[string]interface{}{
+		"user_id": invitee,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "player", userInvite["role"])
+	require.Equal(t, float64(1), userInvite["max_uses"])
+
+	myInvites, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/invites", nil, inviteeHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, myInvites, 1)
+	userToken := myInvites[0]["token"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/"+userToken+"/accept", nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	member, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/invites/"+userToken+"/accept", map[string]bool{
+		"join": true,
+	}, inviteeHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "player", member["role"])
+	require.Equal(t, true, member["joined"])
+
+	currentWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, inviteeHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, worldID, currentWorld["world_id"])
+
+	// accepting again doesn't use the invite up further
+	member, resp = DoRequest[map[string]interface{}](t, http.MethodPost, "/invites/"+userToken+"/accept", nil, inviteeHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "player", member["role"])
+	require.Nil(t, member["joined"])
+
+	// a shareable link with a single use
+	linkInvite, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/invites", map[string]interface{}{
+		"max_uses": 1,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	linkToken := linkInvite["token"].(string)
+
+	invites, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/invites", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, invites, 1)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, linkUserHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/"+linkToken+"/accept", nil, linkUserHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, linkUserHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/"+linkToken+"/accept", nil, strangerHeaders)
+	require.Equal(t, http.StatusGone, resp.StatusCode)
+
+	// revoked links can't be used
+	revokedInvite, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/invites", nil, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID+"/invites/"+revokedInvite["id"].(string), nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/"+revokedInvite["token"].(string)+"/accept", nil, strangerHeaders)
+	require.Equal(t, http.StatusGone, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/not-a-token/accept", nil, strangerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAcceptInviteIntoFullWorld(t *testing.T) {
+	owner := uuid.New().String()
+	guest := uuid.New().String()
+	other := uuid.New().String()
+	for _, userID := range []string{owner, guest, other} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	guestHeaders := map[string]string{"Authorization": "Bearer " + guest}
+	otherHeaders := map[string]string{"Authorization": "Bearer " + other}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]interface{}{
+		"name":        "Tiny Private World",
+		"description": "from e2e test",
+		"visibility":  "private",
+		"max_players": 1,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	invite, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/invites", map[string]interface{}{
+		"max_uses": 2,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	token := invite["token"].(string)
+
+	// the world is full, the invite is still accepted
+	member, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/invites/"+token+"/accept", map[string]bool{
+		"join": true,
+	}, guestHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "player", member["role"])
+	require.Equal(t, false, member["joined"])
+	require.Equal(t, "world_full", member["join_error"].(map[string]interface{})["code"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, guestHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/my-current", nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// retrying works and doesn't spend a second use
+	member, resp = DoRequest[map[string]interface{}](t, http.MethodPost, "/invites/"+token+"/accept", map[string]bool{
+		"join": true,
+	}, guestHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, true, member["joined"])
+
+	invites, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/invites", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, invites, 1)
+	require.Equal(t, float64(1), invites[0]["uses"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/invites/"+token+"/accept", nil, otherHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
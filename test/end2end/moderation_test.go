@@ -0,0 +1,132 @@
+package end2end
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKickAndBan(t *testing.T) {
+	owner := uuid.New().String()
+	player := uuid.New().String()
+	for _, userID := range []string{owner, player} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Moderated World",
+		"description": "from e2e test",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/kick/"+owner, nil, playerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/kick/"+owner, nil, ownerHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/kick/"+player, nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	currentWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, currentWorld["world_id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/kick/"+player, nil, ownerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// kicked users can come back, banned ones can't
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ban, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/bans", map[string]interface{}{
+		"user_id":    player,
+		"reason":     "griefing",
+		"expires_at": time.Now().Add(time.Hour),
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "griefing", ban["reason"])
+
+	currentWorld, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, currentWorld["world_id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	var errorResponse map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+	require.Equal(t, "user_banned", errorResponse["code"])
+
+	bans, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/bans", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, bans, 1)
+	require.Equal(t, player, bans[0]["user_id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/bans", map[string]interface{}{
+		"user_id":    player,
+		"expires_at": time.Now().Add(-time.Hour),
+	}, ownerHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID+"/bans/"+player, nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestKickAndBanAfterPresenceExpired(t *testing.T) {
+	owner := uuid.New().String()
+	player := uuid.New().String()
+	for _, userID := range []string{owner, player} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Quiet World",
+		"description": "from e2e test",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	// players whose presence key expired are still in the world until the sweeper runs
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	expirePresence(t, player)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/kick/"+player, nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	fetched, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), fetched["user_count"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	expirePresence(t, player)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/bans", map[string]interface{}{
+		"user_id": player,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	fetched, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), fetched["user_count"])
+}
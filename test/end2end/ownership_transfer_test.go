@@ -0,0 +1,94 @@
+package end2end
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnershipTransfer(t *testing.T) {
+	owner := uuid.New().String()
+	recipient := uuid.New().String()
+	stranger := uuid.New().String()
+	for _, userID := range []string{owner, recipient, stranger} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	recipientHeaders := map[string]string{"Authorization": "Bearer " + recipient}
+	strangerHeaders := map[string]string{"Authorization": "Bearer " + stranger}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Handed Over",
+		"description": "from e2e test",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+	transferPath := "/worlds/" + worldID + "/ownership-transfer"
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath, map[string]string{"user_id": recipient}, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath, map[string]string{"user_id": owner}, ownerHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// a declined offer can't be accepted anymore
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath, map[string]string{"user_id": recipient}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath+"/decline", nil, recipientHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath+"/accept", nil, recipientHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	transfer, resp := DoRequest[map[string]interface{}](t, http.MethodPost, transferPath, map[string]string{"user_id": recipient}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "pending", transfer["status"])
+	require.Equal(t, owner, transfer["from_user_id"])
+
+	pending, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/ownership-transfers", nil, recipientHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, pending, 1)
+	require.Equal(t, worldID, pending[0]["world_id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, transferPath, nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, transferPath+"/accept", nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	transferred, resp := DoRequest[map[string]interface{}](t, http.MethodPost, transferPath+"/accept", nil, recipientHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, recipient, transferred["user_id"])
+	version := int(transferred["version"].(float64))
+
+	collaborators, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/collaborators", nil, recipientHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	roles := map[string]interface{}{}
+	for _, collaborator := range collaborators {
+		roles[collaborator["user_id"].(string)] = collaborator["role"]
+	}
+	require.Equal(t, map[string]interface{}{recipient: "owner", owner: "editor"}, roles)
+
+	// the previous owner stays on as an editor
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, map[string]string{
+		"name":        "Renamed By Editor",
+		"description": "from e2e test",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	diff, resp := DoRequest[map[string]interface{}](t, http.MethodGet,
+		"/worlds/"+worldID+"/revisions/diff?from=0&to="+strconv.Itoa(version), nil, recipientHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	changes := diff["changes"].([]interface{})
+	require.Len(t, changes, 1)
+	require.Equal(t, "user_id", changes[0].(map[string]interface{})["field"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, transferPath, nil, recipientHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,75 @@
+package end2end
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// expirePresence deletes the user's presence key like its TTL running out
+// before the sweeper got to the user
+func expirePresence(t *testing.T, userID string) {
+	t.Helper()
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		redisURL = "redis://localhost:6379"
+	}
+	opt, err := redis.ParseURL(redisURL)
+	require.NoError(t, err)
+
+	redisClient := redis.NewClient(opt)
+	defer redisClient.Close()
+	require.NoError(t, redisClient.Del(context.Background(), "user:"+userID+":world").Err())
+}
+
+func TestJoinAndLeaveAfterPresenceExpired(t *testing.T) {
+	player := uuid.New().String()
+	other := uuid.New().String()
+	for _, userID := range []string{player, other} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+	otherHeaders := map[string]string{"Authorization": "Bearer " + other}
+
+	worldIDs := []string{}
+	for _, name := range []string{"Old World", "New World"} {
+		world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]interface{}{
+			"name":        name,
+			"description": "from e2e test",
+			"max_players": 1,
+		}, playerHeaders)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		worldIDs = append(worldIDs, world["id"].(string))
+	}
+	oldWorldID, newWorldID := worldIDs[0], worldIDs[1]
+
+	// joining another world leaves the old one even after the presence key expired
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/worlds/"+oldWorldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	expirePresence(t, player)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+newWorldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	oldWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+oldWorldID, nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), oldWorld["user_count"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+oldWorldID+"/join", nil, otherHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// so does leaving
+	expirePresence(t, player)
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/my-current", nil, playerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	newWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+newWorldID, nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), newWorld["user_count"])
+}
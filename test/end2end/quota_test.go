@@ -0,0 +1,67 @@
+package end2end
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// TestWorldQuotas relies on the default player limits: 10 worlds, 10 creations an hour
+func TestWorldQuotas(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+
+	quota, resp := DoRequest[map[string]map[string]interface{}](t, http.MethodGet, "/users/me/quota", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), quota["worlds"]["used"])
+	maxWorlds := int(quota["worlds"]["limit"].(float64))
+	require.Equal(t, 10, maxWorlds)
+
+	worldIDs := []string{}
+	for i := 0; i < maxWorlds; i++ {
+		world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+			"name":        "Quota World " + strconv.Itoa(i),
+			"description": "from e2e test",
+		}, authHeaders)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		worldIDs = append(worldIDs, world["id"].(string))
+	}
+
+	newWorld := map[string]string{
+		"name":        "One Too Many",
+		"description": "from e2e test",
+	}
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	var quotaError map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&quotaError))
+	require.Equal(t, "world_quota_exceeded", quotaError["code"])
+	require.Equal(t, float64(maxWorlds), quotaError["limit"])
+
+	quota, resp = DoRequest[map[string]map[string]interface{}](t, http.MethodGet, "/users/me/quota", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(maxWorlds), quota["worlds"]["used"])
+	require.Equal(t, float64(maxWorlds), quota["creations"]["used"])
+
+	// deleting frees up the quota, but not the creation rate limit
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldIDs[0], nil, authHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+	var rateError map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rateError))
+	require.Equal(t, "rate_limited", rateError["code"])
+	require.Equal(t, float64(10), rateError["limit"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs[1]+"/fork", nil, authHeaders)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
@@ -0,0 +1,122 @@
+package end2end
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// serviceToken is listed in AUTH_SERVICE_TOKENS by make run-local and docker-compose.yml
+const serviceToken = "dev-service-token"
+
+func TestIngestWorld(t *testing.T) {
+	worldID := uuid.New().String()
+	ownerID := uuid.New().String()
+	serviceHeaders := map[string]string{"Authorization": "Bearer " + serviceToken}
+
+	world := map[string]interface{}{
+		"id":          worldID,
+		"user_id":     ownerID,
+		"name":        "Transferred World",
+		"description": "from e2e test",
+		"version":     5,
+		"visibility":  "public",
+	}
+
+	_, resp := DoRequest[interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// user tokens are not service tokens
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, map[string]string{"Authorization": "Bearer " + ownerID})
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	result, resp := DoRequest[map[string]interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, serviceHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, float64(5), result["version"])
+
+	// the owner was created along with the world
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + ownerID}
+	stored, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, ownerID, stored["user_id"])
+	require.Equal(t, float64(5), stored["version"])
+	require.Equal(t, "Transferred World", stored["name"])
+
+	// sending the same version again changes nothing
+	result, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, serviceHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(5), result["version"])
+
+	requireCollaboratorRoles(t, worldID, ownerHeaders, map[string]interface{}{ownerID: "owner"})
+
+	// a newer version can come with a new owner, the previous one stays on as an editor
+	newOwnerID := uuid.New().String()
+	world["version"] = 7
+	world["name"] = "Transferred World v7"
+	world["user_id"] = newOwnerID
+	result, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, serviceHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(7), result["version"])
+	ownerHeaders = map[string]string{"Authorization": "Bearer " + newOwnerID}
+	requireCollaboratorRoles(t, worldID, ownerHeaders, map[string]interface{}{newOwnerID: "owner", ownerID: "editor"})
+
+	world["version"] = 6
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/internal/worlds/"+worldID, world, serviceHeaders)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	var staleError map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&staleError))
+	require.Equal(t, "stale_version", staleError["code"])
+	require.Equal(t, float64(7), staleError["current_version"])
+
+	stored, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(7), stored["version"])
+	require.Equal(t, "Transferred World v7", stored["name"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/internal/worlds/"+uuid.New().String(), world, serviceHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetTransferredWorld(t *testing.T) {
+	ownerID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+ownerID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + ownerID}
+	serviceHeaders := map[string]string{"Authorization": "Bearer " + serviceToken}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Private World",
+		"description": "from e2e test",
+		"visibility":  "private",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/internal/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// other environments see private worlds too
+	transferred, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/internal/worlds/"+worldID, nil, serviceHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, worldID, transferred["id"])
+	require.Equal(t, world["version"], transferred["version"])
+	require.Equal(t, "private", transferred["visibility"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/internal/worlds/"+uuid.New().String(), nil, serviceHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// requireCollaboratorRoles checks the world's collaborators by user ID
+func requireCollaboratorRoles(t *testing.T, worldID string, headers map[string]string, expected map[string]interface{}) {
+	t.Helper()
+	collaborators, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/collaborators", nil, headers)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	roles := map[string]interface{}{}
+	for _, collaborator := range collaborators {
+		roles[collaborator["user_id"].(string)] = collaborator["role"]
+	}
+	require.Equal(t, expected, roles)
+}
@@ -0,0 +1,78 @@
+package end2end
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type WorldRevisionsPage struct {
+	Revisions  []map[string]interface{} `json:"revisions"`
+	NextBefore *int                     `json:"next_before"`
+}
+
+func TestWorldRevisions(t *testing.T) {
+	owner := uuid.New().String()
+	stranger := uuid.New().String()
+	for _, userID := range []string{owner, stranger} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	strangerHeaders := map[string]string{"Authorization": "Bearer " + stranger}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Original Name",
+		"description": "original description",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+worldID, map[string]string{
+		"name":        "Renamed",
+		"description": "original description",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page, resp := DoRequest[WorldRevisionsPage](t, http.MethodGet, "/worlds/"+worldID+"/revisions", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Revisions, 2)
+	require.Equal(t, float64(1), page.Revisions[0]["version"])
+	require.Equal(t, owner, page.Revisions[0]["author_id"])
+	require.Nil(t, page.NextBefore)
+
+	page, resp = DoRequest[WorldRevisionsPage](t, http.MethodGet, "/worlds/"+worldID+"/revisions?limit=1", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Revisions, 1)
+	require.NotNil(t, page.NextBefore)
+
+	revision, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/revisions/0", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Original Name", revision["snapshot"].(map[string]interface{})["name"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID+"/revisions/42", nil, ownerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID+"/revisions", nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	diff, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID+"/revisions/diff?from=0&to=1", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	changes := diff["changes"].([]interface{})
+	require.Len(t, changes, 1)
+	change := changes[0].(map[string]interface{})
+	require.Equal(t, "name", change["field"])
+	require.Equal(t, "Original Name", change["from"])
+	require.Equal(t, "Renamed", change["to"])
+
+	reverted, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/revert/0", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Original Name", reverted["name"])
+	require.Equal(t, float64(2), reverted["version"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/revert/0", nil, strangerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
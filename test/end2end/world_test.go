@@ -2,9 +2,11 @@ package end2end
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -14,6 +16,11 @@ import (
 var baseURL = "http://localhost:8080"
 var client = &http.Client{}
 
+type WorldsPage struct {
+	Worlds     []map[string]interface{} `json:"worlds"`
+	NextCursor *string                  `json:"next_cursor"`
+}
+
 func DoRequest[T any](t *testing.T, method, path string, body interface{}, headers map[string]string) (T, *http.Response) {
 	t.Helper()
 
@@ -62,10 +69,10 @@ func TestWorldsLifecycle(t *testing.T) {
 	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
 	require.Equal(t, http.StatusCreated, resp.StatusCode)
 
-	worlds, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds", nil, nil)
+	page, resp := DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+userID, nil, nil)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 
-	oldLen := len(worlds)
+	oldLen := len(page.Worlds)
 
 	newWorld := map[string]string{
 		"name":        "Test World",
@@ -77,10 +84,14 @@ func TestWorldsLifecycle(t *testing.T) {
 	require.Equal(t, "Test World", created["name"])
 	require.NotEmpty(t, created["id"])
 
-	worlds, resp = DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds", nil, nil)
+	page, resp = DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+userID, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Worlds, oldLen+1)
+	require.Equal(t, "Test World", page.Worlds[0]["name"])
+
+	page, resp = DoRequest[WorldsPage](t, http.MethodGet, "/worlds", nil, nil)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
-	require.Len(t, worlds, oldLen+1)
-	require.Equal(t, "Test World", worlds[0]["name"])
+	require.Equal(t, created["id"], page.Worlds[0]["id"])
 }
 
 func TestCannotEditWorldsFromOtherUsers(t *testing.T) {
@@ -105,7 +116,7 @@ func TestCannotEditWorldsFromOtherUsers(t *testing.T) {
 
 	authHeadersUserB := map[string]string{"Authorization": "Bearer " + userB}
 	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldAID, newWorld, authHeadersUserB)
-	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
 }
 
 func TestUpdateWorld(t *testing.T) {
@@ -132,11 +143,11 @@ func TestUpdateWorld(t *testing.T) {
 	require.Equal(t, updatedWorldResponse["name"], updatedWorld["name"])
 	require.Equal(t, updatedWorldResponse["description"], updatedWorld["description"])
 
-	worlds, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds?ownerId="+user, nil, authHeaders)
+	page, resp := DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+user, nil, authHeaders)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
-	require.Len(t, worlds, 1)
-	require.Equal(t, updatedWorldResponse["name"], worlds[0]["name"])
-	require.Equal(t, updatedWorldResponse["description"], worlds[0]["description"])
+	require.Len(t, page.Worlds, 1)
+	require.Equal(t, updatedWorldResponse["name"], page.Worlds[0]["name"])
+	require.Equal(t, updatedWorldResponse["description"], page.Worlds[0]["description"])
 }
 
 func TestGetWorldsByOwnerID(t *testing.T) {
@@ -157,10 +168,10 @@ func TestGetWorldsByOwnerID(t *testing.T) {
 	newWorldA, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeadersUserA)
 	require.Equal(t, http.StatusCreated, resp.StatusCode)
 
-	worlds, resp := DoRequest[[]map[string]interface{}](t, http.MethodGet, "/worlds?ownerId="+userA, nil, authHeadersUserA)
+	page, resp := DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+userA, nil, authHeadersUserA)
 	require.Equal(t, http.StatusOK, resp.StatusCode)
-	require.Len(t, worlds, 1)
-	require.Equal(t, newWorldA["name"], worlds[0]["name"])
+	require.Len(t, page.Worlds, 1)
+	require.Equal(t, newWorldA["name"], page.Worlds[0]["name"])
 }
 
 func TestJoinWorldAndGetCurrentWorld(t *testing.T) {
@@ -195,3 +206,636 @@ func TestJoinWorldAndGetCurrentWorld(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 	require.Equal(t, worldID, currentWorld["world_id"])
 }
+
+func TestGetWorldsPagination(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	for _, name := range []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"} {
+		newWorld := map[string]string{
+			"name":        name,
+			"description": "from e2e test",
+		}
+		_, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	names := []interface{}{}
+	path := "/worlds?ownerId=" + userID + "&sort=name&order=asc&limit=2"
+	for {
+		page, resp := DoRequest[WorldsPage](t, http.MethodGet, path, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.LessOrEqual(t, len(page.Worlds), 2)
+		for _, world := range page.Worlds {
+			names = append(names, world["name"])
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/worlds?ownerId=" + userID + "&sort=name&order=asc&limit=2&cursor=" + *page.NextCursor
+	}
+	require.Equal(t, []interface{}{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}, names)
+
+	page, resp := DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+userID+"&namePrefix=Ch", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Worlds, 1)
+	require.Equal(t, "Charlie", page.Worlds[0]["name"])
+
+	_, resp = DoRequest[WorldsPage](t, http.MethodGet, "/worlds?cursor=not-a-cursor", nil, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// names default to A to Z
+	page, resp = DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+userID+"&sort=name&limit=1", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Alpha", page.Worlds[0]["name"])
+
+	badCursor, err := json.Marshal(map[string]interface{}{"s": "user_count", "d": true, "v": "lots", "id": uuid.New()})
+	require.NoError(t, err)
+	_, resp = DoRequest[WorldsPage](t, http.MethodGet, "/worlds?sort=user_count&cursor="+base64.RawURLEncoding.EncodeToString(badCursor), nil, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetWorldsByUserCountIncludesEmptyWorlds(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	worldIDs := map[string]string{}
+	for _, name := range []string{"Alpha", "Bravo", "Charlie"} {
+		world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+			"name":        name,
+			"description": "from e2e test",
+		}, authHeaders)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		worldIDs[name] = world["id"].(string)
+	}
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["Bravo"]+"/join", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for _, order := range []string{"desc", "asc"} {
+		userCounts := []interface{}{}
+		ids := []interface{}{}
+		path := "/worlds?ownerId=" + userID + "&sort=user_count&order=" + order + "&limit=1"
+		for {
+			page, resp := DoRequest[WorldsPage](t, http.MethodGet, path, nil, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			for _, world := range page.Worlds {
+				userCounts = append(userCounts, world["user_count"])
+				ids = append(ids, world["id"])
+			}
+			if page.NextCursor == nil {
+				break
+			}
+			path = "/worlds?ownerId=" + userID + "&sort=user_count&order=" + order + "&limit=1&cursor=" + *page.NextCursor
+		}
+
+		require.Len(t, ids, 3, order)
+		require.ElementsMatch(t, []interface{}{worldIDs["Alpha"], worldIDs["Bravo"], worldIDs["Charlie"]}, ids)
+		if order == "desc" {
+			require.Equal(t, []interface{}{float64(1), float64(0), float64(0)}, userCounts)
+		} else {
+			require.Equal(t, []interface{}{float64(0), float64(0), float64(1)}, userCounts)
+		}
+	}
+}
+
+func TestSearchWorlds(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// a unique word keeps results from other tests out of the way
+	keyword := "zq" + strings.ReplaceAll(uuid.New().String()[:8], "-", "")
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	newWorld := map[string]string{
+		"name":        "Volcano " + keyword,
+		"description": "A world full of lava and dragons",
+	}
+	created, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	type searchPage struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	page, resp := DoRequest[searchPage](t, http.MethodGet, "/worlds/search?q="+keyword, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Results, 1)
+	require.Equal(t, created["id"], page.Results[0]["id"])
+	require.Contains(t, page.Results[0]["name_highlight"], "<mark>")
+
+	updatedWorld := map[string]string{
+		"name":        "Glacier " + keyword,
+		"description": "A frozen world",
+	}
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+created["id"].(string), updatedWorld, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page, resp = DoRequest[searchPage](t, http.MethodGet, "/worlds/search?q="+keyword+"+dragons", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, page.Results)
+
+	_, resp = DoRequest[searchPage](t, http.MethodGet, "/worlds/search", nil, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	markupWorld := map[string]string{
+		"name":        `<img src=x onerror="alert(1)"> ` + keyword,
+		"description": "<script>alert('" + keyword + "')</script>",
+	}
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+created["id"].(string), markupWorld, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page, resp = DoRequest[searchPage](t, http.MethodGet, "/worlds/search?q="+keyword, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Results, 1)
+	require.Equal(t, `&lt;img src=x onerror=&quot;alert(1)&quot;&gt; <mark>`+keyword+`</mark>`, page.Results[0]["name_highlight"])
+	require.NotContains(t, page.Results[0]["description_snippet"], "<script>")
+	require.Contains(t, page.Results[0]["description_snippet"], "&lt;script&gt;")
+}
+
+func TestActiveWorldsAndUserCount(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	newWorld := map[string]string{
+		"name":        "Busy World",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	fetched, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), fetched["user_count"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	fetched, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(1), fetched["user_count"])
+
+	found := false
+	path := "/worlds/active?limit=100"
+	for !found {
+		page, resp := DoRequest[WorldsPage](t, http.MethodGet, path, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		for _, activeWorld := range page.Worlds {
+			require.Greater(t, activeWorld["user_count"], float64(0))
+			if activeWorld["id"] == worldID {
+				found = true
+			}
+		}
+		if page.NextCursor == nil {
+			break
+		}
+		path = "/worlds/active?limit=100&cursor=" + *page.NextCursor
+	}
+	require.True(t, found)
+}
+
+func TestLeaveWorld(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	newWorld := map[string]string{
+		"name":        "World to leave",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/leave", nil, authHeaders)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/leave", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	currentWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, currentWorld["world_id"])
+
+	fetched, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), fetched["user_count"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/my-current", nil, authHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	currentWorld, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, currentWorld["world_id"])
+}
+
+func TestHeartbeat(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/my-current/heartbeat", nil, authHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	newWorld := map[string]string{
+		"name":        "Heartbeat World",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	heartbeat, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/my-current/heartbeat", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, worldID, heartbeat["world_id"])
+	require.Greater(t, heartbeat["expires_in"], float64(0))
+}
+
+func TestJoinFullWorld(t *testing.T) {
+	owner := uuid.New().String()
+	player := uuid.New().String()
+	for _, userID := range []string{owner, player} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+
+	newWorld := map[string]interface{}{
+		"name":        "Tiny World",
+		"description": "from e2e test",
+		"max_players": 1,
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, float64(1), world["max_players"])
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// joining again while already inside does not count against the limit
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	var errorResponse map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+	require.Equal(t, "world_full", errorResponse["code"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+uuid.New().String()+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds", map[string]interface{}{
+		"name":        "Negative World",
+		"description": "from e2e test",
+		"max_players": -1,
+	}, ownerHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDeleteAndRestoreWorld(t *testing.T) {
+	owner := uuid.New().String()
+	player := uuid.New().String()
+	for _, userID := range []string{owner, player} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	playerHeaders := map[string]string{"Authorization": "Bearer " + player}
+
+	newWorld := map[string]string{
+		"name":        "World to delete",
+		"description": "from e2e test",
+	}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", newWorld, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/join", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID, nil, playerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodDelete, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// everyone in the world was removed from it
+	currentWorld, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/my-current", nil, playerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, currentWorld["world_id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/restore", nil, playerHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	restored, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldID+"/restore", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, worldID, restored["id"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldID+"/restore", nil, ownerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateWorldWithIfMatch(t *testing.T) {
+	user := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+user, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + user}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Versioned World",
+		"description": "from e2e test",
+	}, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodGet, "/worlds/"+worldID, nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	update := map[string]string{
+		"name":        "Versioned World v2",
+		"description": "from e2e test",
+	}
+	conditionalHeaders := map[string]string{"Authorization": "Bearer " + user, "If-Match": etag}
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+worldID, update, conditionalHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	newETag := resp.Header.Get("ETag")
+	require.NotEqual(t, etag, newETag)
+
+	// the same ETag is now stale
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, update, conditionalHeaders)
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	require.Equal(t, newETag, resp.Header.Get("ETag"))
+	var conflict map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&conflict))
+	require.Equal(t, "version_conflict", conflict["code"])
+	require.NotNil(t, conflict["current_version"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, update, map[string]string{
+		"Authorization": "Bearer " + user,
+		"If-Match":      "not-an-etag",
+	})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// If-Match uses strong comparison, so a weak ETag never matches
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+worldID, update, map[string]string{
+		"Authorization": "Bearer " + user,
+		"If-Match":      "W/" + newETag,
+	})
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	var weakError map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&weakError))
+	require.Equal(t, "weak_etag", weakError["code"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPut, "/worlds/"+uuid.New().String(), update, authHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPatchWorld(t *testing.T) {
+	user := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+user, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + user}
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]interface{}{
+		"name":        "Patchable World",
+		"description": "from e2e test",
+		"max_players": 10,
+	}, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	mergePatchHeaders := map[string]string{
+		"Authorization": "Bearer " + user,
+		"Content-Type":  "application/merge-patch+json",
+	}
+	patched, resp := DoRequest[map[string]interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"description": "patched description",
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Patchable World", patched["name"])
+	require.Equal(t, "patched description", patched["description"])
+	require.Equal(t, float64(10), patched["max_players"])
+
+	// null removes the limit
+	patched, resp = DoRequest[map[string]interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"max_players": nil,
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(0), patched["max_players"])
+
+	patched, resp = DoRequest[map[string]interface{}](t, http.MethodPatch, "/worlds/"+worldID, []map[string]interface{}{
+		{"op": "replace", "path": "/name", "value": "JSON Patched World"},
+	}, map[string]string{
+		"Authorization": "Bearer " + user,
+		"Content-Type":  "application/json-patch+json",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "JSON Patched World", patched["name"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"name": "x",
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"user_id": uuid.New().String(),
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"name": "Stale Patch",
+	}, map[string]string{
+		"Authorization": "Bearer " + user,
+		"Content-Type":  "application/merge-patch+json",
+		"If-Match":      `"0"`,
+	})
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	// a patch that changes nothing doesn't make a new version
+	unchanged, resp := DoRequest[map[string]interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"name": "JSON Patched World",
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, patched["version"], unchanged["version"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{}, mergePatchHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldID, map[string]interface{}{
+		"description": strings.Repeat("a", 70<<10),
+	}, mergePatchHeaders)
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func TestForkWorld(t *testing.T) {
+	author := uuid.New().String()
+	forker := uuid.New().String()
+	for _, userID := range []string{author, forker} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	authorHeaders := map[string]string{"Authorization": "Bearer " + author}
+	forkerHeaders := map[string]string{"Authorization": "Bearer " + forker}
+
+	source, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]interface{}{
+		"name":        "Template World",
+		"description": "from e2e test",
+		"max_players": 8,
+	}, authorHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	sourceID := source["id"].(string)
+
+	fork, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+sourceID+"/fork", nil, forkerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEqual(t, sourceID, fork["id"])
+	require.Equal(t, forker, fork["user_id"])
+	require.Equal(t, "Template World", fork["name"])
+	require.Equal(t, float64(8), fork["max_players"])
+	require.Equal(t, sourceID, fork["forked_from_id"])
+	require.Equal(t, float64(0), fork["forked_from_version"])
+
+	// the forker owns the copy and can edit it
+	_, resp = DoRequest[map[string]interface{}](t, http.MethodPut, "/worlds/"+fork["id"].(string), map[string]string{
+		"name":        "My Fork",
+		"description": "from e2e test",
+	}, forkerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	renamed, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+sourceID+"/fork", map[string]string{
+		"name": "Renamed Fork",
+	}, forkerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "Renamed Fork", renamed["name"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+uuid.New().String()+"/fork", nil, forkerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWorldVisibility(t *testing.T) {
+	owner := uuid.New().String()
+	collaborator := uuid.New().String()
+	stranger := uuid.New().String()
+	for _, userID := range []string{owner, collaborator, stranger} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	ownerHeaders := map[string]string{"Authorization": "Bearer " + owner}
+	collaboratorHeaders := map[string]string{"Authorization": "Bearer " + collaborator}
+	strangerHeaders := map[string]string{"Authorization": "Bearer " + stranger}
+
+	worldIDs := map[string]string{}
+	for _, visibility := range []string{"public", "unlisted", "private"} {
+		world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+			"name":        visibility + " world",
+			"description": "from e2e test",
+			"visibility":  visibility,
+		}, ownerHeaders)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		require.Equal(t, visibility, world["visibility"])
+		worldIDs[visibility] = world["id"].(string)
+	}
+
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["private"]+"/collaborators", map[string]string{
+		"user_id": collaborator,
+		"role":    "viewer",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	listedNames := func(headers map[string]string) []string {
+		page, resp := DoRequest[WorldsPage](t, http.MethodGet, "/worlds?ownerId="+owner, nil, headers)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		names := []string{}
+		for _, world := range page.Worlds {
+			names = append(names, world["name"].(string))
+		}
+		return names
+	}
+	require.ElementsMatch(t, []string{"public world"}, listedNames(nil))
+	require.ElementsMatch(t, []string{"public world"}, listedNames(strangerHeaders))
+	require.ElementsMatch(t, []string{"public world", "private world"}, listedNames(collaboratorHeaders))
+	require.ElementsMatch(t, []string{"public world", "unlisted world", "private world"}, listedNames(ownerHeaders))
+
+	// unlisted worlds can still be opened and joined by ID
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldIDs["unlisted"], nil, strangerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["unlisted"]+"/join", nil, strangerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldIDs["private"], nil, strangerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["private"]+"/join", nil, strangerHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/worlds/"+worldIDs["private"], nil, collaboratorHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["private"]+"/join", nil, collaboratorHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "secret world",
+		"description": "from e2e test",
+		"visibility":  "secret",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// only the owner decides who can see the world
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/"+worldIDs["public"]+"/collaborators", map[string]string{
+		"user_id": collaborator,
+		"role":    "editor",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldIDs["public"], map[string]interface{}{
+		"visibility": "private",
+	}, collaboratorHeaders)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	edited, resp := DoRequest[map[string]interface{}](t, http.MethodPatch, "/worlds/"+worldIDs["public"], map[string]interface{}{
+		"description": "edited by a collaborator",
+	}, collaboratorHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "public", edited["visibility"])
+
+	// reverting to a revision from when the world was public keeps it private
+	_, resp = DoRequest[interface{}](t, http.MethodPatch, "/worlds/"+worldIDs["public"], map[string]interface{}{
+		"visibility": "private",
+	}, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	reverted, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/"+worldIDs["public"]+"/revert/0", nil, ownerHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "from e2e test", reverted["description"])
+	require.Equal(t, "private", reverted["visibility"])
+}
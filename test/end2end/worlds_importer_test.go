@@ -0,0 +1,132 @@
+package end2end
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorldsImporterRoutes(t *testing.T) {
+	userID := uuid.New().String()
+	_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+userID, nil, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Exported World",
+		"description": "from e2e test",
+	}, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	importRequest := map[string]interface{}{
+		"worlds":             []string{world["id"].(string)},
+		"target_environment": "nowhere",
+	}
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/import", importRequest, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/import", importRequest, authHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/worlds/import", map[string]interface{}{
+		"worlds":             []string{},
+		"target_environment": "nowhere",
+	}, authHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/jobs/status/"+uuid.New().String(), nil, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/jobs/status/not-a-uuid", nil, authHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodGet, "/jobs/status/"+uuid.New().String(), nil, authHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/jobs/"+uuid.New().String()+"/cancel", nil, nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/jobs/not-a-uuid/cancel", nil, authHeaders)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/jobs/"+uuid.New().String()+"/cancel", nil, authHeaders)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// targetEnvironmentAddr is where the e2e environment of make run-local and
+// docker-compose.dev.yml points
+const targetEnvironmentAddr = ":8089"
+
+// startTargetEnvironment serves the e2e environment, which has no worlds and
+// can't take any, so transfers to it stay queued
+func startTargetEnvironment(t *testing.T) {
+	t.Helper()
+	listener, err := net.Listen("tcp", targetEnvironmentAddr)
+	require.NoError(t, err)
+
+	target := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	target.Listener.Close()
+	target.Listener = listener
+	target.Start()
+	t.Cleanup(target.Close)
+}
+
+func TestCancelQueuedTransferJob(t *testing.T) {
+	startTargetEnvironment(t)
+
+	userID := uuid.New().String()
+	otherID := uuid.New().String()
+	for _, id := range []string{userID, otherID} {
+		_, resp := DoRequest[interface{}](t, http.MethodPost, "/user/"+id, nil, nil)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	authHeaders := map[string]string{"Authorization": "Bearer " + userID}
+
+	world, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds", map[string]string{
+		"name":        "Queued World",
+		"description": "from e2e test",
+	}, authHeaders)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	worldID := world["id"].(string)
+
+	job, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/worlds/import", map[string]interface{}{
+		"worlds":             []string{worldID},
+		"target_environment": "e2e",
+	}, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "queued", job["status"])
+	jobID := job["job_id"].(string)
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/jobs/"+jobID+"/cancel", nil, map[string]string{"Authorization": "Bearer " + otherID})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	cancelled, resp := DoRequest[map[string]interface{}](t, http.MethodPost, "/jobs/"+jobID+"/cancel", nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "cancelled", cancelled["status"])
+	require.NotNil(t, cancelled["cancelled_at"])
+	worlds := cancelled["worlds"].(map[string]interface{})
+	require.Equal(t, "cancelled", worlds[worldID].(map[string]interface{})["status"])
+
+	status, resp := DoRequest[map[string]interface{}](t, http.MethodGet, "/jobs/status/"+jobID, nil, authHeaders)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "cancelled", status["status"])
+
+	_, resp = DoRequest[interface{}](t, http.MethodPost, "/jobs/"+jobID+"/cancel", nil, authHeaders)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	var errorResponse map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+	require.Equal(t, "job_finished", errorResponse["code"])
+}